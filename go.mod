@@ -2,8 +2,4 @@ module github.com/l0nax/go-spew
 
 go 1.16
 
-require (
-	github.com/gookit/color v1.4.2
-	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
-	github.com/modern-go/reflect2 v1.0.1
-)
+require github.com/gookit/color v1.4.2
@@ -4,9 +4,6 @@ import (
 	"io"
 	"reflect"
 	"unsafe"
-
-	gcolor "github.com/gookit/color"
-	"github.com/modern-go/reflect2"
 )
 
 // Type represents a GoLang (basic) type and semantics.
@@ -45,6 +42,18 @@ const (
 	TLen Type = iota + 200
 	TCap
 	TArgs
+	// TCircular is the marker of an already shown (circular) reference.
+	TCircular
+	// TMaxDepth is the marker of a reached maximum depth.
+	TMaxDepth
+	// TFieldName is the name of a struct field.
+	TFieldName
+	// TRedacted is the marker of a redacted struct field.
+	TRedacted
+	// TTruncated is the marker of omitted elements, bytes or output.
+	TTruncated
+	// TPath is the Go expression of a value, see AnnotatePaths.
+	TPath
 )
 
 // colors used in the hex dump
@@ -60,106 +69,98 @@ const (
 	TNULByte
 )
 
-// pre-defined colors
-var (
-	cBlue          = gcolor.HEX("#82aaff", false)
-	cPurple        = gcolor.HEX("#c792ea", false)
-	cOrange        = gcolor.HEX("#f78c6c", false)
-	cSpecial       = gcolor.HEXStyle("#ffffff", "#c17e70")
-	cGreen         = gcolor.HEX("#c3e88d", false)
-	cDarkGreen     = gcolor.HEX("#138040", false)
-	cRadiantYellow = gcolor.HEX("#ffea00", false)
+// colors used in diffs
+const (
+	// TDiffRemoved is the old value of a changed path.
+	TDiffRemoved Type = iota + 400
+	// TDiffAdded is the new value of a changed path.
+	TDiffAdded
 )
 
+// ColorPrinter renders text in a color.  All color types of the
+// github.com/gookit/color package implement it.
 type ColorPrinter interface {
 	Sprint(a ...interface{}) string
 }
 
-var colorPalette = map[Type]ColorPrinter{
-	TInteger: cBlue,
-	TFloat:   cBlue,
-	TDate:    gcolor.Green.RGB(),
-	TString:  gcolor.LightYellow.RGB(),
-	TBool:    gcolor.LightBlue.RGB(),
-	TNil:     gcolor.LightMagenta.RGB(),
-	TArray:   gcolor.LightWhite.RGB(),
-	TStruct:  gcolor.Yellow.RGB(),
-
-	TTInteger:   gcolor.Cyan.RGB(),
-	TTFloat:     gcolor.Cyan.RGB(),
-	TTMap:       gcolor.LightRed.RGB(),
-	TTDate:      gcolor.Green.RGB(),
-	TTString:    cOrange,
-	TTBool:      cPurple,
-	TTArray:     cGreen,
-	TTPtr:       gcolor.Magenta.RGB(),
-	TTAddress:   cDarkGreen,
-	TTInterface: cRadiantYellow,
-
-	TLen:  cSpecial,
-	TCap:  cSpecial,
-	TArgs: cGreen,
-
-	TNonPrintable:    gcolor.Red.RGB(),
-	TPrintable:       cOrange,
-	TBase10:          cRadiantYellow,
-	TWhitespaceChar:  cDarkGreen,
-	TPunctuationChar: cPurple,
-	TNULByte:         gcolor.Gray.RGB(),
-}
-
+// colorWriter wraps the destination writer of a single dump operation and
+// colorizes everything written through it with the currently selected color.
+//
+// Every dump owns its own colorWriter, so concurrent dumps never share any
+// color state.  All methods are safe to call on a nil *colorWriter, in which
+// case they do nothing.
 type colorWriter struct {
-	origWriter        io.Writer
-	globalDisabled    bool
-	globalHexDisabled bool
-	disabled          bool
-	col               ColorPrinter
+	origWriter io.Writer
+	theme      Theme
+	col        ColorPrinter
 }
 
-func stopColor() {
-	cWriter.disabled = true
+// newColorWriter returns a colorWriter writing to w which uses the theme
+// selected by cs, adapted to a destination rendering lvl colors.
+func newColorWriter(w io.Writer, cs *ConfigState, lvl colorLevel) *colorWriter {
+	return &colorWriter{
+		origWriter: w,
+		theme:      cs.theme().forLevel(lvl),
+	}
 }
 
 func (c *colorWriter) Write(p []byte) (n int, err error) {
-	if c.globalDisabled || c.disabled || c.col == nil {
+	if c.col == nil || len(p) == 0 {
 		return c.origWriter.Write(p)
 	}
 
 	str := c.col.Sprint(byteSlice2String(p))
 
-	return c.origWriter.Write(s2b(str))
+	// report the number of bytes consumed from p, not the (larger) number
+	// of colorized bytes written to the underlying writer
+	if _, err = c.origWriter.Write(s2b(str)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
 }
 
-var cWriter *colorWriter
+// stopColor disables colorization until the next color is selected.
+func (c *colorWriter) stopColor() {
+	if c == nil {
+		return
+	}
+
+	c.col = nil
+}
 
 // rawColor allows to use our internal types DIRECTLY.
 // Please ONLY use this function if the type is known – skipping the overhead
 // of the reflect package (calling the methods and "searching" the correct color).
-func rawColor(t Type) {
-	col, ok := colorPalette[t]
+func (c *colorWriter) rawColor(t Type) {
+	if c == nil {
+		return
+	}
 
-	cWriter.disabled = !ok
-	cWriter.col = col
+	c.col = c.theme[t]
 }
 
-func specialColor(t Type) {
+func (c *colorWriter) specialColor(t Type) {
+	if c == nil {
+		return
+	}
+
 	switch t {
 	case TLen, TCap, TArgs:
-		cWriter.disabled = false
-		cWriter.col = colorPalette[t]
+		c.col = c.theme[t]
 	}
 }
 
 // hexColor is special since it will return the COLORIZED string directly.
-func hexColor(t Type, data []byte) []byte {
+func hexColor(theme Theme, t Type, data []byte) []byte {
 	switch t {
 	case TNonPrintable, TPrintable, TBase10, TWhitespaceChar, TPunctuationChar, TNULByte:
 	default:
 		return data
 	}
 
-	c, ok := colorPalette[t]
-	if !ok {
+	c, ok := theme[t]
+	if !ok || c == nil {
 		return data
 	}
 
@@ -169,71 +170,85 @@ func hexColor(t Type, data []byte) []byte {
 // colorPtr handles the special case where we're searching the color for pointers.
 // This function exists since there are some special cases, e.g. time.Time or bytes.Buffer,
 // which have a different color than a "normal" pointer.
-func colorPtr(t string) {
+func (c *colorWriter) colorPtr(t string) {
+	if c == nil {
+		return
+	}
+
 	switch t {
+	case "time.Time", "time.Duration", "time.Location":
+		c.col = c.theme[TTDate]
 	case "*bytes.Buffer":
 		// require special color
 		fallthrough
 	default:
-		cWriter.disabled = false
-		cWriter.col = colorPalette[TTPtr]
+		c.col = c.theme[TTPtr]
 	}
 }
 
-func color(t reflect.Value) {
-	cWriter.disabled = false
-	typ := reflect2.Type2(t.Type())
+// color selects the color of the value described by n, which must not be a
+// pointer.
+func (c *colorWriter) color(n *Node) {
+	if c == nil {
+		return
+	}
+
+	c.col = nil
 
 	// special case: value is nil
-	switch typ.Kind() {
-	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr,
-		reflect.UnsafePointer, reflect.Interface, reflect.Slice:
-		if t.IsNil() {
-			cWriter.col = colorPalette[TNil]
-			return
-		}
+	if n.Nil {
+		c.col = c.theme[TNil]
+		return
 	}
 
-	switch typ.Kind() {
+	switch n.Kind {
 	case reflect.String:
-		cWriter.col = colorPalette[TString]
+		c.col = c.theme[TString]
 	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
 		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
 		reflect.Complex64, reflect.Complex128:
-		cWriter.col = colorPalette[TInteger]
+		c.col = c.theme[TInteger]
 	case reflect.Float32, reflect.Float64:
-		cWriter.col = colorPalette[TFloat]
+		c.col = c.theme[TFloat]
 	case reflect.Bool:
-		cWriter.col = colorPalette[TBool]
-	default:
-		cWriter.disabled = true
+		c.col = c.theme[TBool]
+	case reflect.Uintptr, reflect.UnsafePointer, reflect.Chan, reflect.Func:
+		c.col = c.theme[TTAddress]
 	}
 }
 
-func typeColor(t reflect.Type) {
-	cWriter.disabled = false
+func (c *colorWriter) typeColor(t reflect.Type) {
+	if c == nil {
+		return
+	}
+
+	c.col = nil
+
+	switch t {
+	case timeType, durationType, locationType:
+		c.col = c.theme[TTDate]
+		return
+	}
 
 	switch t.Kind() {
 	case reflect.Ptr:
-		cWriter.col = colorPalette[TTPtr]
+		c.col = c.theme[TTPtr]
 	case reflect.String:
-		cWriter.col = colorPalette[TTString]
+		c.col = c.theme[TTString]
 	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
 		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
 		reflect.Complex64, reflect.Complex128:
-		cWriter.col = colorPalette[TTInteger]
+		c.col = c.theme[TTInteger]
 	case reflect.Float32, reflect.Float64:
-		cWriter.col = colorPalette[TTFloat]
+		c.col = c.theme[TTFloat]
 	case reflect.Map:
-		cWriter.col = colorPalette[TTMap]
+		c.col = c.theme[TTMap]
 	case reflect.Bool:
-		cWriter.col = colorPalette[TTBool]
+		c.col = c.theme[TTBool]
 	case reflect.Interface:
-		cWriter.col = colorPalette[TTInterface]
+		c.col = c.theme[TTInterface]
 	case reflect.Array, reflect.Slice:
-		cWriter.col = colorPalette[TTArray]
-	default:
-		cWriter.disabled = true
+		c.col = c.theme[TTArray]
 	}
 }
 
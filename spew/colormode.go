@@ -0,0 +1,309 @@
+package spew
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	gcolor "github.com/gookit/color"
+)
+
+// ColorMode specifies when colors are emitted if HighlightValues is enabled.
+type ColorMode int
+
+const (
+	// ColorAuto emits colors only if the destination is a terminal which
+	// supports them.  Terminals are detected on Linux, the BSDs, macOS and
+	// Windows.  It honors the NO_COLOR, FORCE_COLOR, TERM and COLORTERM
+	// environment variables.
+	ColorAuto ColorMode = iota
+
+	// ColorAlways emits colors regardless of the destination, e.g. to
+	// produce colorized log files.  The colors of the Theme are used as is,
+	// unless the TERM and COLORTERM environment variables describe a
+	// terminal which renders fewer colors.
+	ColorAlways
+
+	// ColorNever never emits colors.
+	ColorNever
+)
+
+// colorLevel is the number of colors a destination is able to render.
+type colorLevel int
+
+const (
+	levelNone colorLevel = iota
+	level16
+	level256
+	levelTrueColor
+)
+
+// lookupEnv is used to read the environment.  It is a variable so the tests
+// can replace it.
+var lookupEnv = os.LookupEnv
+
+// getenv returns the value of the environment variable key, or an empty
+// string if it is not set.
+func getenv(key string) string {
+	val, _ := lookupEnv(key)
+	return val
+}
+
+// colorLevel returns the number of colors to use for output written to w,
+// according to the ColorMode of c.  A nil w is never considered a terminal.
+func (c *ConfigState) colorLevel(w io.Writer) colorLevel {
+	if !c.HighlightValues {
+		return levelNone
+	}
+
+	switch c.ColorMode {
+	case ColorNever:
+		return levelNone
+
+	case ColorAlways:
+		if lvl := envColorLevel(); lvl != levelNone {
+			return lvl
+		}
+
+		return levelTrueColor
+	}
+
+	if getenv("NO_COLOR") != "" {
+		return levelNone
+	}
+
+	if force, ok := forcedColorLevel(); ok {
+		return force
+	}
+
+	if getenv("TERM") == "dumb" || !isTerminal(w) {
+		return levelNone
+	}
+
+	if lvl := envColorLevel(); lvl > level16 {
+		return lvl
+	}
+
+	return level16
+}
+
+// forcedColorLevel returns the color level requested by the FORCE_COLOR
+// environment variable, if it is set.
+func forcedColorLevel() (colorLevel, bool) {
+	force, ok := lookupEnv("FORCE_COLOR")
+	if !ok {
+		return levelNone, false
+	}
+
+	switch strings.ToLower(force) {
+	case "0", "false":
+		return levelNone, true
+	case "2":
+		return level256, true
+	case "3":
+		return levelTrueColor, true
+	}
+
+	if lvl := envColorLevel(); lvl > level16 {
+		return lvl, true
+	}
+
+	return level16, true
+}
+
+// envColorLevel returns the number of colors the terminal described by the
+// TERM and COLORTERM environment variables supports.
+func envColorLevel() colorLevel {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return levelTrueColor
+	}
+
+	// Windows Terminal does not set COLORTERM, but supports true color.
+	if getenv("WT_SESSION") != "" {
+		return levelTrueColor
+	}
+
+	term := getenv("TERM")
+	switch {
+	case term == "" || term == "dumb":
+		return levelNone
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "24bit"):
+		return levelTrueColor
+	case strings.Contains(term, "256"):
+		return level256
+	}
+
+	return level16
+}
+
+// isTerminal returns whether w is a file which refers to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || f == nil {
+		return false
+	}
+
+	return isTerminalFd(f.Fd())
+}
+
+// ansiPrinter renders text with a fixed ANSI SGR code.  Unlike the printers
+// of the github.com/gookit/color package it does not consult the global
+// terminal detection of that package, so the decision made by ColorMode is
+// always respected.
+type ansiPrinter string
+
+func (p ansiPrinter) Sprint(a ...interface{}) string {
+	return "\x1b[" + string(p) + "m" + fmt.Sprint(a...) + "\x1b[0m"
+}
+
+// forLevel returns t adapted to a destination which renders lvl colors, or
+// nil if lvl is levelNone.  Colors of the github.com/gookit/color package
+// are downsampled as needed, other ColorPrinters are used as is.
+func (t Theme) forLevel(lvl colorLevel) Theme {
+	if lvl == levelNone || t == nil {
+		return nil
+	}
+
+	adapted := make(Theme, len(t))
+	for typ, col := range t {
+		adapted[typ] = adaptPrinter(col, lvl)
+	}
+
+	return adapted
+}
+
+// adaptPrinter returns a ColorPrinter rendering col with at most lvl colors.
+func adaptPrinter(col ColorPrinter, lvl colorLevel) ColorPrinter {
+	var code string
+
+	switch c := col.(type) {
+	case gcolor.Color:
+		code = c.String()
+	case gcolor.Style:
+		code = c.String()
+	case gcolor.Color256:
+		code = c.String()
+	case *gcolor.Style256:
+		code = c.String()
+	case gcolor.RGBColor:
+		code = c.String()
+	case *gcolor.RGBStyle:
+		code = c.String()
+	default:
+		return col
+	}
+
+	code = downsampleCode(code, lvl)
+	if code == "" {
+		return nil
+	}
+
+	return ansiPrinter(code)
+}
+
+// downsampleCode converts the 256 and true color parts of the ANSI SGR code
+// to their closest approximation with lvl colors.
+func downsampleCode(code string, lvl colorLevel) string {
+	if lvl == levelTrueColor || code == "" {
+		return code
+	}
+
+	parts := strings.Split(code, ";")
+	out := make([]string, 0, len(parts))
+
+	for i := 0; i < len(parts); i++ {
+		isColor := (parts[i] == "38" || parts[i] == "48") && i+1 < len(parts)
+		isBg := parts[i] == "48"
+
+		switch {
+		case isColor && parts[i+1] == "2" && i+4 < len(parts):
+			r, g, b := atou8(parts[i+2]), atou8(parts[i+3]), atou8(parts[i+4])
+			i += 4
+
+			if lvl == level256 {
+				out = append(out, parts[i-4], "5", strconv.Itoa(int(gcolor.RgbTo256(r, g, b))))
+				continue
+			}
+
+			out = append(out, strconv.Itoa(rgbToBasic(r, g, b, isBg)))
+
+		case isColor && parts[i+1] == "5" && i+2 < len(parts):
+			if lvl == level256 {
+				out = append(out, parts[i:i+3]...)
+				i += 2
+				continue
+			}
+
+			out = append(out, strconv.Itoa(c256ToBasic(atou8(parts[i+2]), isBg)))
+			i += 2
+
+		default:
+			out = append(out, parts[i])
+		}
+	}
+
+	return strings.Join(out, ";")
+}
+
+// basicColors holds the RGB values of the 16 basic colors as rendered by
+// xterm.
+var basicColors = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// basicCode returns the ANSI SGR code of the basic color with index idx.
+func basicCode(idx int, isBg bool) int {
+	base := 30
+	if idx >= 8 {
+		base = 90 - 8
+	}
+
+	if isBg {
+		base += 10
+	}
+
+	return base + idx
+}
+
+// rgbToBasic returns the ANSI SGR code of the basic color closest to the
+// passed RGB color.
+func rgbToBasic(r, g, b uint8, isBg bool) int {
+	best, bestDist := 0, -1
+	for idx, c := range basicColors {
+		dr, dg, db := int(r)-c[0], int(g)-c[1], int(b)-c[2]
+		dist := dr*dr + dg*dg + db*db
+
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = idx, dist
+		}
+	}
+
+	return basicCode(best, isBg)
+}
+
+// c256ToBasic returns the ANSI SGR code of the basic color closest to the
+// passed 256 color.
+func c256ToBasic(c uint8, isBg bool) int {
+	if c < 16 {
+		return basicCode(int(c), isBg)
+	}
+
+	rgb := gcolor.C256ToRgb(c)
+	if len(rgb) != 3 {
+		return basicCode(7, isBg)
+	}
+
+	return rgbToBasic(rgb[0], rgb[1], rgb[2], isBg)
+}
+
+// atou8 converts s to an uint8, returning 0 if that is not possible.
+func atou8(s string) uint8 {
+	n, _ := strconv.ParseUint(s, 10, 8)
+	return uint8(n)
+}
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"time"
 )
 
 // Some constants in the form of bytes to avoid string overhead.  This mirrors
@@ -31,8 +32,6 @@ var (
 	panicBytes            = []byte("(PANIC=")
 	plusBytes             = []byte("+")
 	iBytes                = []byte("i")
-	trueBytes             = []byte("true")
-	falseBytes            = []byte("false")
 	interfaceBytes        = []byte("(interface {})")
 	commaNewlineBytes     = []byte(",\n")
 	newlineBytes          = []byte("\n")
@@ -40,6 +39,9 @@ var (
 	openBraceNewlineBytes = []byte("{\n")
 	closeBraceBytes       = []byte("}")
 	asteriskBytes         = []byte("*")
+	ampersandBytes        = []byte("&")
+	pointerRefBytes       = []byte("-> ")
+	sameAsBytes           = []byte("<same as ")
 	colonBytes            = []byte(":")
 	colonSpaceBytes       = []byte(": ")
 	openParenBytes        = []byte("(")
@@ -47,10 +49,11 @@ var (
 	spaceBytes            = []byte(" ")
 	pointerChainBytes     = []byte("->")
 	nilAngleBytes         = []byte("<nil>")
-	maxNewlineBytes       = []byte("<max depth reached>\n")
+	maxDepthBytes         = []byte("<max depth reached>")
 	maxShortBytes         = []byte("<max>")
 	circularBytes         = []byte("<already shown>")
 	circularShortBytes    = []byte("<shown>")
+	redactedBytes         = []byte("<redacted>")
 	invalidAngleBytes     = []byte("<invalid>")
 	openBracketBytes      = []byte("[")
 	closeBracketBytes     = []byte("]")
@@ -83,12 +86,10 @@ func catchPanic(w io.Writer, v reflect.Value) {
 	}
 }
 
-// handleMethods attempts to call the Error and String methods on the underlying
-// type the passed reflect.Value represents and outputes the result to Writer w.
-//
-// It handles panics in any called methods by catching and displaying the error
-// as the formatted value.
-func handleMethods(cs *ConfigState, w io.Writer, v reflect.Value) (handled bool) {
+// methodReceiver returns the value to look up the methods spew calls on,
+// e.g. the error and Stringer interfaces, for the passed reflect.Value.  The
+// returned bool is false if there is no way to call methods on v.
+func methodReceiver(cs *ConfigState, v reflect.Value) (reflect.Value, bool) {
 	// We need an interface to check if the type implements the error or
 	// Stringer interface.  However, the reflect package won't give us an
 	// interface on certain things like unexported struct fields in order
@@ -97,7 +98,7 @@ func handleMethods(cs *ConfigState, w io.Writer, v reflect.Value) (handled bool)
 	// values.
 	if !v.CanInterface() {
 		if UnsafeDisabled {
-			return false
+			return v, false
 		}
 
 		v = unsafeReflectValue(v)
@@ -116,43 +117,67 @@ func handleMethods(cs *ConfigState, w io.Writer, v reflect.Value) (handled bool)
 		v = v.Addr()
 	}
 
+	return v, true
+}
+
+// handleMethods attempts to call the Error and String methods on the underlying
+// type the passed reflect.Value represents and outputes the result to Writer w.
+//
+// It handles panics in any called methods by catching and displaying the error
+// as the formatted value.
+func handleMethods(cs *ConfigState, w io.Writer, v reflect.Value) (handled bool) {
+	v, ok := methodReceiver(cs, v)
+	if !ok {
+		return false
+	}
+
 	// Is it an error or Stringer?
-	switch iface := v.Interface().(type) {
-	case error:
-		defer catchPanic(w, v)
-		if cs.ContinueOnMethod {
-			w.Write(openParenBytes)
-			w.Write([]byte(iface.Error()))
-			w.Write(closeParenBytes)
-			w.Write(spaceBytes)
-			return false
-		}
+	r, ok := callMethod(v)
+	if !ok {
+		return false
+	}
 
-		w.Write([]byte(iface.Error()))
-		return true
+	return r.write(cs, w)
+}
 
-	case fmt.Stringer:
-		defer catchPanic(w, v)
-		if cs.ContinueOnMethod {
-			w.Write(openParenBytes)
-			w.Write([]byte(iface.String()))
-			w.Write(closeParenBytes)
-			w.Write(spaceBytes)
-			return false
+var (
+	// timeType, durationType and locationType are the reflect.Types of the
+	// time package which are rendered in a human readable form.
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	locationType = reflect.TypeOf(time.Location{})
+)
+
+// timeString returns the human readable representation of time.Time (RFC3339),
+// time.Duration and time.Location values.  The returned bool is false if v
+// does not hold one of these types or its value is inaccessible.
+func timeString(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String(), true
+	case timeType, locationType:
+	default:
+		return "", false
+	}
+
+	// Unexported struct fields can only be accessed by bypassing the
+	// visibility rules, see handleMethods.
+	if !v.CanInterface() {
+		if UnsafeDisabled {
+			return "", false
 		}
-		w.Write([]byte(iface.String()))
-		return true
+
+		v = unsafeReflectValue(v)
 	}
-	return false
-}
 
-// printBool outputs a boolean value as true or false to Writer w.
-func printBool(w io.Writer, val bool) {
-	if val {
-		w.Write(trueBytes)
-	} else {
-		w.Write(falseBytes)
+	switch val := v.Interface().(type) {
+	case time.Time:
+		return val.Format(time.RFC3339Nano), true
+	case time.Location:
+		return val.String(), true
 	}
+
+	return "", false
 }
 
 // printInt outputs a signed integer value to Writer w.
@@ -160,17 +185,6 @@ func printInt(w io.Writer, val int64, base int) {
 	w.Write([]byte(strconv.FormatInt(val, base)))
 }
 
-// printUint outputs an unsigned integer value to Writer w.
-func printUint(w io.Writer, val uint64, base int) {
-	w.Write([]byte(strconv.FormatUint(val, base)))
-}
-
-// printFloat outputs a floating point value using the specified precision,
-// which is expected to be 32 or 64bit, to Writer w.
-func printFloat(w io.Writer, val float64, precision int) {
-	w.Write([]byte(strconv.FormatFloat(val, 'g', -1, precision)))
-}
-
 // printComplex outputs a complex value using the specified float precision
 // for the real and imaginary parts to Writer w.
 func printComplex(w io.Writer, c complex128, floatPrecision int) {
@@ -220,6 +234,18 @@ func printHexPtr(w io.Writer, p uintptr) {
 	w.Write(buf)
 }
 
+// printSharedPath outputs the reference to the path of an earlier appearance
+// of a pointer target, e.g. <same as .Nodes[0].Parent>, to Writer w.
+func printSharedPath(w io.Writer, path string) {
+	if path == "" {
+		path = "."
+	}
+
+	w.Write(sameAsBytes)
+	w.Write([]byte(path))
+	w.Write(closeAngleBytes)
+}
+
 // valuesSorter implements sort.Interface to allow a slice of reflect.Value
 // elements to be sorted.
 type valuesSorter struct {
@@ -18,9 +18,11 @@ package spew
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 )
 
 // ConfigState houses the configuration options used by spew to format and
@@ -49,8 +51,33 @@ type ConfigState struct {
 	// nested data structures.
 	MaxDepth int
 
-	// DisableMethods specifies whether or not error and Stringer interfaces are
-	// invoked for types that implement them.
+	// MaxElements controls the maximum number of elements of arrays, slices
+	// and maps which are displayed.  The remaining ones are summarized by a
+	// "... N more" marker.  The default, 0, means there is no limit.  Byte
+	// arrays and slices which are hex dumped are limited by MaxBytes instead.
+	MaxElements int
+
+	// MaxStringLen controls the maximum number of bytes of strings which are
+	// displayed.  Longer strings are cut at a character boundary and followed
+	// by a "... N more bytes" marker.  The default, 0, means there is no
+	// limit.
+	MaxStringLen int
+
+	// MaxBytes controls the maximum number of bytes of byte arrays and
+	// slices which are hex dumped.  Only the first and last bytes of longer
+	// ones are dumped, separated by a "... N bytes omitted" marker.  The
+	// default, 0, means there is no limit.
+	MaxBytes int
+
+	// MaxOutputBytes controls the maximum number of bytes a single Dump or
+	// Formatter invocation outputs.  Once it is reached, the value is not
+	// walked any further and an "<output truncated>" marker ends the output.
+	// Color escape sequences do not count towards the limit.  The default,
+	// 0, means there is no limit.
+	MaxOutputBytes int
+
+	// DisableMethods specifies whether or not error, Stringer, SpewDumper and
+	// SpewFormatter interfaces are invoked for types that implement them.
 	DisableMethods bool
 
 	// DisablePointerMethods specifies whether or not to check for and invoke
@@ -71,6 +98,32 @@ type ConfigState struct {
 	// pointer addresses. This is useful when diffing data structures in tests.
 	DisablePointerAddresses bool
 
+	// PointerIDs specifies whether to display ordinals such as &1 instead of
+	// pointer addresses.  The ordinals are assigned to pointer targets in the
+	// order of their first appearance, and later pointers to the same
+	// target are displayed as -> &1 instead of dumping it again.  This shows
+	// shared data while the output stays the same across runs.  It takes
+	// precedence over DisablePointerAddresses.
+	PointerIDs bool
+
+	// DetectSharedPointers specifies whether to display later pointers to an
+	// already displayed target as a reference to the path of its first
+	// appearance, e.g. <same as .Nodes[0].Parent>, instead of displaying it
+	// again.  Otherwise only circular references are detected.  PointerIDs
+	// takes precedence over it.
+	DetectSharedPointers bool
+
+	// AnnotatePaths specifies whether Dump style output displays the Go
+	// expression each nested value is accessed by as a comment after it,
+	// e.g. // v.Nested.Bytes or // v.Labels["app"], so it can be copied
+	// into code or a debugger watch expression.  The Formatter output is
+	// never annotated.
+	AnnotatePaths bool
+
+	// PathRoot is the name of the dumped variable the expressions of
+	// AnnotatePaths start with.  The default, "", means v is used.
+	PathRoot string
+
 	// DisableCapacities specifies whether to disable the printing of capacities
 	// for arrays, slices, maps and channels. This is useful when diffing
 	// data structures in tests.
@@ -99,11 +152,42 @@ type ConfigState struct {
 	// considered if SortKeys is true.
 	SpewKeys bool
 
-	// HighlightValues adds colour/color to scalar values in output.
+	// HighlightValues adds colour/color to scalar values in output.  It
+	// applies to both Dump style output and the custom Formatter.
 	HighlightValues bool
 
 	// HighlightHex adds, if HighlightValues is true, colour/color to the hex dump in output.
 	HighlightHex bool
+
+	// HexDumpOptions controls the layout of the hex dumps of byte arrays and
+	// slices in Dump style and JSON output.  The zero value produces the
+	// layout of the hexdump -C command.  Its Theme is ignored in favor of
+	// HighlightHex.
+	HexDumpOptions HexDumpOptions
+
+	// ColorMode specifies when colors are emitted if HighlightValues is
+	// true.  The default, ColorAuto, only emits colors when writing to a
+	// terminal that supports them.
+	ColorMode ColorMode
+
+	// Theme specifies the colors used when HighlightValues is true.  The
+	// default, nil, means DefaultTheme is used.  See Theme for the
+	// predefined themes and for deriving custom ones.
+	Theme Theme
+
+	// FieldRules specifies struct fields which are skipped or redacted in
+	// addition to the ones tagged with `spew:"-"` or `spew:"redact"`.  The
+	// first matching rule is applied.  See FieldRule for details.
+	FieldRules []FieldRule
+
+	// JSONHexDump specifies whether JSON output includes a hexdump -C style
+	// dump of byte arrays and slices in addition to their base64 encoded
+	// contents.
+	JSONHexDump bool
+
+	// formatters holds the custom formatters registered with
+	// RegisterFormatter.
+	formatters map[reflect.Type]FormatterFunc
 }
 
 // Config is the active configuration of the top-level functions.
@@ -119,7 +203,7 @@ var Config = ConfigState{Indent: " ", HighlightValues: true, HighlightHex: true}
 //
 //	fmt.Errorf(format, c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Errorf(format string, a ...interface{}) (err error) {
-	return fmt.Errorf(format, c.convertArgs(a)...)
+	return fmt.Errorf(format, c.convertArgs(nil, a)...)
 }
 
 // Fprint is a wrapper for fmt.Fprint that treats each argument as if it were
@@ -131,7 +215,7 @@ func (c *ConfigState) Errorf(format string, a ...interface{}) (err error) {
 //
 //	fmt.Fprint(w, c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Fprint(w io.Writer, a ...interface{}) (n int, err error) {
-	return fmt.Fprint(w, c.convertArgs(a)...)
+	return fmt.Fprint(w, c.convertArgs(w, a)...)
 }
 
 // Fprintf is a wrapper for fmt.Fprintf that treats each argument as if it were
@@ -143,7 +227,7 @@ func (c *ConfigState) Fprint(w io.Writer, a ...interface{}) (n int, err error) {
 //
 //	fmt.Fprintf(w, format, c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Fprintf(w io.Writer, format string, a ...interface{}) (n int, err error) {
-	return fmt.Fprintf(w, format, c.convertArgs(a)...)
+	return fmt.Fprintf(w, format, c.convertArgs(w, a)...)
 }
 
 // Fprintln is a wrapper for fmt.Fprintln that treats each argument as if it
@@ -154,7 +238,7 @@ func (c *ConfigState) Fprintf(w io.Writer, format string, a ...interface{}) (n i
 //
 //	fmt.Fprintln(w, c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Fprintln(w io.Writer, a ...interface{}) (n int, err error) {
-	return fmt.Fprintln(w, c.convertArgs(a)...)
+	return fmt.Fprintln(w, c.convertArgs(w, a)...)
 }
 
 // Print is a wrapper for fmt.Print that treats each argument as if it were
@@ -166,7 +250,7 @@ func (c *ConfigState) Fprintln(w io.Writer, a ...interface{}) (n int, err error)
 //
 //	fmt.Print(c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Print(a ...interface{}) (n int, err error) {
-	return fmt.Print(c.convertArgs(a)...)
+	return fmt.Print(c.convertArgs(os.Stdout, a)...)
 }
 
 // Printf is a wrapper for fmt.Printf that treats each argument as if it were
@@ -178,7 +262,7 @@ func (c *ConfigState) Print(a ...interface{}) (n int, err error) {
 //
 //	fmt.Printf(format, c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Printf(format string, a ...interface{}) (n int, err error) {
-	return fmt.Printf(format, c.convertArgs(a)...)
+	return fmt.Printf(format, c.convertArgs(os.Stdout, a)...)
 }
 
 // Println is a wrapper for fmt.Println that treats each argument as if it were
@@ -190,7 +274,7 @@ func (c *ConfigState) Printf(format string, a ...interface{}) (n int, err error)
 //
 //	fmt.Println(c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Println(a ...interface{}) (n int, err error) {
-	return fmt.Println(c.convertArgs(a)...)
+	return fmt.Println(c.convertArgs(os.Stdout, a)...)
 }
 
 // Sprint is a wrapper for fmt.Sprint that treats each argument as if it were
@@ -201,7 +285,7 @@ func (c *ConfigState) Println(a ...interface{}) (n int, err error) {
 //
 //	fmt.Sprint(c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Sprint(a ...interface{}) string {
-	return fmt.Sprint(c.convertArgs(a)...)
+	return fmt.Sprint(c.convertArgs(nil, a)...)
 }
 
 // Sprintf is a wrapper for fmt.Sprintf that treats each argument as if it were
@@ -212,7 +296,7 @@ func (c *ConfigState) Sprint(a ...interface{}) string {
 //
 //	fmt.Sprintf(format, c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Sprintf(format string, a ...interface{}) string {
-	return fmt.Sprintf(format, c.convertArgs(a)...)
+	return fmt.Sprintf(format, c.convertArgs(nil, a)...)
 }
 
 // Sprintln is a wrapper for fmt.Sprintln that treats each argument as if it
@@ -223,7 +307,7 @@ func (c *ConfigState) Sprintf(format string, a ...interface{}) string {
 //
 //	fmt.Sprintln(c.NewFormatter(a), c.NewFormatter(b))
 func (c *ConfigState) Sprintln(a ...interface{}) string {
-	return fmt.Sprintln(c.convertArgs(a)...)
+	return fmt.Sprintln(c.convertArgs(nil, a)...)
 }
 
 /*
@@ -250,7 +334,7 @@ func (c *ConfigState) NewFormatter(v interface{}) fmt.Formatter {
 // Fdump formats and displays the passed arguments to io.Writer w.  It formats
 // exactly the same as Dump.
 func (c *ConfigState) Fdump(w io.Writer, a ...interface{}) {
-	fdump(c, w, a...)
+	fdump(context.Background(), c, w, a...)
 }
 
 /*
@@ -277,28 +361,120 @@ See Fdump if you would prefer dumping to an arbitrary io.Writer or Sdump to
 get the formatted result as a string.
 */
 func (c *ConfigState) Dump(a ...interface{}) {
-	fdump(c, os.Stdout, a...)
+	fdump(context.Background(), c, os.Stdout, a...)
 }
 
 // Sdump returns a string with the passed arguments formatted exactly the same
 // as Dump.
 func (c *ConfigState) Sdump(a ...interface{}) string {
 	var buf bytes.Buffer
-	fdump(c, &buf, a...)
+	fdump(context.Background(), c, &buf, a...)
+	return buf.String()
+}
+
+// FdumpContext is like Fdump, but stops dumping once ctx is done.  See the
+// package level FdumpContext function for details.
+func (c *ConfigState) FdumpContext(ctx context.Context, w io.Writer, a ...interface{}) error {
+	return fdump(ctx, c, w, a...)
+}
+
+// SdumpContext is like Sdump, but stops dumping once ctx is done.  See the
+// package level FdumpContext function for details.
+func (c *ConfigState) SdumpContext(ctx context.Context, a ...interface{}) string {
+	var buf bytes.Buffer
+	fdump(ctx, c, &buf, a...)
 	return buf.String()
 }
 
+// Fjson writes the passed arguments to io.Writer w as JSON.  It describes
+// exactly the same as Sjson.  The only possible errors are write errors.
+func (c *ConfigState) Fjson(w io.Writer, a ...interface{}) error {
+	return fjson(c, w, a...)
+}
+
+// Sjson returns a JSON document for each of the passed arguments, describing
+// the same tree Dump displays.  See the package level Sjson function for
+// details.
+func (c *ConfigState) Sjson(a ...interface{}) string {
+	var buf bytes.Buffer
+	fjson(c, &buf, a...)
+	return buf.String()
+}
+
+// Fliteral writes the passed arguments to io.Writer w as Go literals.  It
+// writes exactly the same as Sliteral.
+func (c *ConfigState) Fliteral(w io.Writer, a ...interface{}) {
+	fliteral(c, w, a...)
+}
+
+// Sliteral returns the passed arguments as Go literals, one per line, which
+// can be pasted into Go source code.  See the package level Sliteral function
+// for details.
+func (c *ConfigState) Sliteral(a ...interface{}) string {
+	var buf bytes.Buffer
+	fliteral(c, &buf, a...)
+	return buf.String()
+}
+
+// Fdiff compares the passed values and writes their differences to
+// io.Writer w.  It reports exactly the same as Diff.
+func (c *ConfigState) Fdiff(w io.Writer, a, b interface{}) {
+	fdiff(c, w, a, b)
+}
+
+// Diff returns the differences of the passed values, one changed path per
+// line, or an empty string if they are equal.  See the package level Diff
+// function for details.
+func (c *ConfigState) Diff(a, b interface{}) string {
+	var buf bytes.Buffer
+	fdiff(c, &buf, a, b)
+	return buf.String()
+}
+
+// FdumpPath dumps the values selected from v by the path query to
+// io.Writer w.  It dumps exactly the same as DumpPath.
+func (c *ConfigState) FdumpPath(w io.Writer, v interface{}, query string) error {
+	return fdumpPath(c, w, v, query)
+}
+
+// DumpPath dumps only the values selected from v by the path query to
+// standard out, each prefixed with its path.  See the package level DumpPath
+// function for the query syntax.
+func (c *ConfigState) DumpPath(v interface{}, query string) error {
+	return fdumpPath(c, os.Stdout, v, query)
+}
+
+// SdumpPath returns a string with the values selected from v by the path
+// query dumped exactly the same as DumpPath.
+func (c *ConfigState) SdumpPath(v interface{}, query string) (string, error) {
+	var buf bytes.Buffer
+	err := fdumpPath(c, &buf, v, query)
+	return buf.String(), err
+}
+
 // convertArgs accepts a slice of arguments and returns a slice of the same
 // length with each argument converted to a spew Formatter interface using
-// the ConfigState associated with s.
-func (c *ConfigState) convertArgs(args []interface{}) (formatters []interface{}) {
+// the ConfigState associated with s.  The formatters assume their output is
+// written to w, which may be nil if it is unknown.
+func (c *ConfigState) convertArgs(w io.Writer, args []interface{}) (formatters []interface{}) {
 	formatters = make([]interface{}, len(args))
 	for index, arg := range args {
-		formatters[index] = newFormatter(c, arg)
+		fs := newFormatter(c, arg)
+		fs.dest = w
+		formatters[index] = fs
 	}
 	return formatters
 }
 
+// theme returns the theme used by dumps performed with c.
+func (c *ConfigState) theme() Theme {
+	if c.Theme == nil {
+		return DefaultTheme
+	}
+
+	return c.Theme
+}
+
 // NewDefaultConfig returns a ConfigState with the following default settings.
 //
 // 	Indent: " "
@@ -0,0 +1,67 @@
+package spew_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// blocker is a Stringer which blocks until its channel is closed.
+type blocker chan struct{}
+
+func (b blocker) String() string {
+	<-b
+	return "unblocked"
+}
+
+// TestDumpContext ensures dumps stop with a canceled marker once their
+// context is done, leaving the partial output well-formed.
+func TestDumpContext(t *testing.T) {
+	cs := &spew.ConfigState{Indent: " "}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := make(blocker)
+	defer close(b)
+
+	v := struct {
+		A int
+		B []interface{}
+		C int
+	}{1, []interface{}{2, b, 3}, 4}
+
+	deadline, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{cs.SdumpContext(context.Background(), 1), "(int) 1\n"},
+		{cs.SdumpContext(canceled, 1, 2), "<canceled: canceled>\n"},
+		{cs.SdumpContext(deadline, v), "(struct { A int; B []interface {}; C int }) {\n" +
+			" A: (int) 1,\n" +
+			" B: ([]interface {}) (len=3 cap=3) {\n" +
+			"  (int) 2,\n" +
+			"  (spew_test.blocker) <canceled: deadline exceeded>\n" +
+			" }\n" +
+			"}\n"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("DumpContext #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+
+	if err := cs.FdumpContext(canceled, io.Discard, 1); err != context.Canceled {
+		t.Errorf("FdumpContext: got error %v, want %v", err, context.Canceled)
+	}
+	if err := cs.FdumpContext(context.Background(), io.Discard, 1); err != nil {
+		t.Errorf("FdumpContext: got error %v, want nil", err)
+	}
+}
@@ -0,0 +1,412 @@
+package spew
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	arrowBytes   = []byte(" -> ")
+	missingBytes = []byte("<missing>")
+)
+
+// diffState contains information about the state of a diff operation.
+type diffState struct {
+	w  io.Writer
+	cs *ConfigState
+
+	// cw is the colorWriter w writes through, if any.
+	cw *colorWriter
+}
+
+// unpackValue returns values inside of non-nil interfaces when possible.
+func unpackValue(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// derefValue follows the first indirects pointers of v, unpacking the
+// interfaces on the way like the walk does.
+func derefValue(v reflect.Value, indirects int) reflect.Value {
+	v = unpackValue(v)
+	for i := 0; i < indirects && v.Kind() == reflect.Ptr && !v.IsNil(); i++ {
+		v = unpackValue(v.Elem())
+	}
+	return v
+}
+
+// valueString renders v in the compact form of the Formatter, prefixed with
+// its type like Dump does.
+func (d *diffState) valueString(v reflect.Value) string {
+	v = unpackValue(v)
+	if !v.IsValid() {
+		return string(nilAngleBytes)
+	}
+
+	typ := "(" + v.Type().String() + ") "
+	if v.Kind() == reflect.String {
+		return typ + strconv.Quote(v.String())
+	}
+
+	return typ + plainString(d.cs, v)
+}
+
+// report writes a single changed path with its old and new value.  A nil
+// value denotes a missing map entry or slice element.
+func (d *diffState) report(path string, a, b *reflect.Value) {
+	if path != "" {
+		d.w.Write([]byte(path))
+		d.w.Write(colonSpaceBytes)
+	}
+
+	d.cw.rawColor(TDiffRemoved)
+	if a == nil {
+		d.w.Write(missingBytes)
+	} else {
+		d.w.Write([]byte(d.valueString(*a)))
+	}
+	d.cw.stopColor()
+
+	d.w.Write(arrowBytes)
+
+	d.cw.rawColor(TDiffAdded)
+	if b == nil {
+		d.w.Write(missingBytes)
+	} else {
+		d.w.Write([]byte(d.valueString(*b)))
+	}
+	d.cw.stopColor()
+
+	d.w.Write(newlineBytes)
+}
+
+// reportNodes reports the values of the nodes a and b, either of which may be
+// nil to denote a missing map entry or slice element.
+func (d *diffState) reportNodes(path string, a, b *Node) {
+	var av, bv *reflect.Value
+	if a != nil {
+		av = &a.val
+	}
+	if b != nil {
+		bv = &b.val
+	}
+	d.report(path, av, bv)
+}
+
+// diffSlice handles comparing arrays and slices element by element.  Hex
+// dumped byte arrays and slices are compared as a whole.
+func (d *diffState) diffSlice(path string, a, b *Node, av, bv reflect.Value) {
+	if a.Nil || b.Nil {
+		if a.Nil != b.Nil {
+			d.report(path, &av, &bv)
+		}
+		return
+	}
+
+	if a.Bytes != nil || b.Bytes != nil {
+		if !bytes.Equal(a.Bytes, b.Bytes) {
+			d.report(path, &av, &bv)
+		}
+		return
+	}
+
+	for i := 0; i < len(a.Children) || i < len(b.Children); i++ {
+		idxPath := indexPath(path, i)
+
+		switch {
+		case i >= len(a.Children):
+			d.reportNodes(idxPath, nil, b.Children[i])
+		case i >= len(b.Children):
+			d.reportNodes(idxPath, a.Children[i], nil)
+		default:
+			d.diff(idxPath, a.Children[i], b.Children[i])
+		}
+	}
+}
+
+// mapEntries holds the entries of two maps whose keys are written the same
+// way in paths, see keyPath.
+type mapEntries struct {
+	a, b []*Node
+}
+
+// diffMap handles comparing maps entry by entry.  The entries are matched by
+// the paths of their keys and reported in the order of their keys,
+// regardless of SortKeys, so the output is the same on every run.
+func (d *diffState) diffMap(path string, a, b *Node, av, bv reflect.Value) {
+	if a.Nil || b.Nil {
+		if a.Nil != b.Nil {
+			d.report(path, &av, &bv)
+		}
+		return
+	}
+
+	entries := make(map[string]*mapEntries)
+	var keys []reflect.Value
+	collect := func(children []*Node, inA bool) {
+		for _, c := range children {
+			kp := keyPath(d.cs, path, c.Key.val)
+			e, ok := entries[kp]
+			if !ok {
+				e = new(mapEntries)
+				entries[kp] = e
+				keys = append(keys, c.Key.val)
+			}
+			if inA {
+				e.a = append(e.a, c)
+			} else {
+				e.b = append(e.b, c)
+			}
+		}
+	}
+	collect(a.Children, true)
+	collect(b.Children, false)
+	sortValues(keys, d.cs)
+
+	for _, key := range keys {
+		kp := keyPath(d.cs, path, key)
+		e := entries[kp]
+		if len(e.a) == 1 && len(e.b) == 1 {
+			d.diff(kp, e.a[0], e.b[0])
+			continue
+		}
+		d.diffEntries(kp, e.a, e.b)
+	}
+}
+
+// diffEntries compares the values a and b of map entries whose keys share
+// the path, such as NaN keys, which can not be told apart otherwise.  Equal
+// values are paired first, the remaining ones are paired in the order of
+// their text.  Unpaired values are reported as missing.
+func (d *diffState) diffEntries(path string, a, b []*Node) {
+	b = append([]*Node(nil), b...)
+
+	var restA []*Node
+	for _, ea := range a {
+		paired := false
+		for i, eb := range b {
+			if !d.differs(ea, eb) {
+				b = append(b[:i], b[i+1:]...)
+				paired = true
+				break
+			}
+		}
+		if !paired {
+			restA = append(restA, ea)
+		}
+	}
+
+	d.sortNodes(restA)
+	d.sortNodes(b)
+	for i := 0; i < len(restA) || i < len(b); i++ {
+		switch {
+		case i >= len(restA):
+			d.reportNodes(path, nil, b[i])
+		case i >= len(b):
+			d.reportNodes(path, restA[i], nil)
+		default:
+			d.diff(path, restA[i], b[i])
+		}
+	}
+}
+
+// sortNodes sorts the nodes by the text of their values, so they are paired
+// and reported in a stable order.
+func (d *diffState) sortNodes(nodes []*Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return d.valueString(nodes[i].val) < d.valueString(nodes[j].val)
+	})
+}
+
+// differs returns whether a and b differ.
+func (d *diffState) differs(a, b *Node) bool {
+	var buf bytes.Buffer
+	sub := diffState{w: &buf, cs: d.cs}
+	sub.diff("", a, b)
+	return buf.Len() != 0
+}
+
+// diffStruct handles comparing structs field by field.  A field which is
+// skipped in either value is skipped in both, and one which is redacted in
+// either value is redacted in both, so hidden values never show up in the
+// differences.
+func (d *diffState) diffStruct(path string, a, b *Node) {
+	// Blank fields share their name, so they are matched in order.
+	fields := make(map[string][]*Node, len(b.Children))
+	for _, c := range b.Children {
+		fields[c.Name] = append(fields[c.Name], c)
+	}
+
+	for _, ca := range a.Children {
+		same := fields[ca.Name]
+		if len(same) == 0 {
+			continue
+		}
+		cb := same[0]
+		fields[ca.Name] = same[1:]
+
+		fp := fieldPath(path, ca.Name)
+		if ca.Redacted || cb.Redacted {
+			d.diffRedacted(fp, ca, cb)
+			continue
+		}
+		d.diff(fp, ca, cb)
+	}
+}
+
+// diffRedacted compares the values a and b of a redacted struct field and
+// reports the path with <redacted> in place of the values if they differ.
+func (d *diffState) diffRedacted(path string, a, b *Node) {
+	if !d.differs(walkRaw(d.cs, a.val), walkRaw(d.cs, b.val)) {
+		return
+	}
+
+	d.w.Write([]byte(path))
+	d.w.Write(colonSpaceBytes)
+	d.cw.rawColor(TDiffRemoved)
+	d.w.Write(redactedBytes)
+	d.cw.stopColor()
+	d.w.Write(arrowBytes)
+	d.cw.rawColor(TDiffAdded)
+	d.w.Write(redactedBytes)
+	d.cw.stopColor()
+	d.w.Write(newlineBytes)
+}
+
+// floatEqual returns whether a and b are equal, treating NaN as equal to
+// itself.
+func floatEqual(a, b float64) bool {
+	return a == b || a != a && b != b
+}
+
+// diff is the main workhorse for comparing two values, which are described by
+// the trees a and b of walkRaw.  It reports every path at which they differ.
+// Circular data structures are detected by the walk, the values which are
+// part of a circular reference are compared further up already.
+func (d *diffState) diff(path string, a, b *Node) {
+	av, bv := unpackValue(a.val), unpackValue(b.val)
+
+	// Handle nil interfaces and differing types immediately.
+	if !av.IsValid() || !bv.IsValid() {
+		if av.IsValid() != bv.IsValid() {
+			d.report(path, &av, &bv)
+		}
+		return
+	}
+	if av.Type() != bv.Type() {
+		d.report(path, &av, &bv)
+		return
+	}
+
+	// Pointers are followed to the values at the end of their chains, so
+	// pointer addresses never cause a difference.
+	if a.isPtr() {
+		switch {
+		case a.Nil || b.Nil:
+			if a.Nil != b.Nil || a.Indirects != b.Indirects {
+				indirects := a.Indirects
+				if b.Indirects < indirects {
+					indirects = b.Indirects
+				}
+				av, bv = derefValue(av, indirects), derefValue(bv, indirects)
+				d.report(path, &av, &bv)
+			}
+			return
+
+		case a.Circular || b.Circular:
+			return
+
+		case a.Addrs[0] == b.Addrs[0]:
+			// Pointers to the same value are equal.
+			return
+		}
+
+		// The types may still differ if interfaces are part of the
+		// chains.
+		av, bv = derefValue(av, a.Indirects), derefValue(bv, b.Indirects)
+		if av.Type() != bv.Type() {
+			d.report(path, &av, &bv)
+			return
+		}
+	}
+
+	equal := true
+	switch {
+	case a.time:
+		// Time values are compared by their human readable form.
+		equal = a.Value == b.Value
+
+	case a.Kind == reflect.Slice || a.Kind == reflect.Array:
+		d.diffSlice(path, a, b, av, bv)
+
+	case a.Kind == reflect.Map:
+		d.diffMap(path, a, b, av, bv)
+
+	case a.Kind == reflect.Struct:
+		d.diffStruct(path, a, b)
+
+	case a.Kind == reflect.Float32 || a.Kind == reflect.Float64:
+		equal = floatEqual(av.Float(), bv.Float())
+
+	case a.Kind == reflect.Complex64 || a.Kind == reflect.Complex128:
+		ac, bc := av.Complex(), bv.Complex()
+		equal = floatEqual(real(ac), real(bc)) && floatEqual(imag(ac), imag(bc))
+
+	default:
+		// All other values are equal if they are displayed the same.
+		equal = a.Nil == b.Nil && a.Value == b.Value
+	}
+
+	if !equal {
+		d.report(path, &av, &bv)
+	}
+}
+
+// fdiff is a helper function to consolidate the logic from the various public
+// methods which take varying writers and config states.
+func fdiff(cs *ConfigState, w io.Writer, a, b interface{}) {
+	cw := newColorWriter(w, cs, cs.colorLevel(w))
+	d := diffState{w: cw, cs: cs, cw: cw}
+	d.diff("", walkRaw(cs, reflect.ValueOf(a)), walkRaw(cs, reflect.ValueOf(b)))
+}
+
+// Fdiff compares the passed values and writes their differences to
+// io.Writer w.  It reports exactly the same as Diff.
+func Fdiff(w io.Writer, a, b interface{}) {
+	fdiff(&Config, w, a, b)
+}
+
+/*
+Diff walks both passed values the same way Dump does and returns their
+differences, one changed path per line:
+
+	.Map["Foo"]: (int) 15 -> (int) 16
+	.List[2]: (string) "c" -> <missing>
+
+Paths consist of struct field names, map keys and slice indices.  Pointers are
+followed, so pointer addresses never cause a difference, and circular data
+structures are detected and handled properly.  The values are compared
+completely and as they are, so limits such as MaxDepth, methods and custom
+formatters are ignored while comparing.  Struct fields skipped by the
+spew struct tag or a FieldRule are not compared, redacted ones are reported
+as <redacted> if they differ.  Floating point NaN values are equal to each
+other, also as map keys.  Map keys are always reported in sorted order, so
+the output is the same on every run.  An empty string means both values are
+equal.
+
+The configuration options are controlled by an exported package global,
+spew.Config.  See ConfigState for options documentation.
+
+See Fdiff if you would prefer writing the differences to an arbitrary
+io.Writer.
+*/
+func Diff(a, b interface{}) string {
+	var buf bytes.Buffer
+	fdiff(&Config, &buf, a, b)
+	return buf.String()
+}
@@ -0,0 +1,241 @@
+package spew_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// diffTest is used to describe a test to be performed against the Diff
+// function.
+type diffTest struct {
+	a, b interface{}
+	want string
+}
+
+type diffInner struct {
+	N    int
+	Tags []string
+}
+
+type diffOuter struct {
+	Name  string
+	Map   map[string]int
+	Inner *diffInner
+	Any   interface{}
+	Data  []byte
+	At    time.Time
+	next  *diffOuter
+}
+
+// TestDiff ensures Diff reports exactly the changed paths.
+func TestDiff(t *testing.T) {
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	base := func() diffOuter {
+		return diffOuter{
+			Name:  "a",
+			Map:   map[string]int{"Foo": 15, "Bar": 1},
+			Inner: &diffInner{N: 1, Tags: []string{"x", "y"}},
+			Any:   1,
+			Data:  []byte{1, 2, 3},
+			At:    at,
+		}
+	}
+
+	changed := base()
+	changed.Map["Foo"] = 16
+	delete(changed.Map, "Bar")
+	changed.Map["Baz"] = 2
+	changed.Inner = &diffInner{N: 2, Tags: []string{"x"}}
+	changed.Any = "1"
+	changed.Data = []byte{1, 2, 4}
+	changed.At = at.Add(time.Second)
+
+	// Circular values with equal contents.
+	cycle1, cycle2 := base(), base()
+	cycle1.next, cycle2.next = &cycle1, &cycle2
+	cycle3 := base()
+	cycle3.Name = "b"
+	cycle3.next = &cycle3
+
+	tests := []diffTest{
+		{1, 1, ""},
+		{1, 2, "(int) 1 -> (int) 2\n"},
+		{1, "1", "(int) 1 -> (string) \"1\"\n"},
+		{nil, nil, ""},
+		{nil, 1, "<nil> -> (int) 1\n"},
+		{base(), base(), ""},
+		{base(), changed,
+			".Map[\"Bar\"]: (int) 1 -> <missing>\n" +
+				".Map[\"Baz\"]: <missing> -> (int) 2\n" +
+				".Map[\"Foo\"]: (int) 15 -> (int) 16\n" +
+				".Inner.N: (int) 1 -> (int) 2\n" +
+				".Inner.Tags[1]: (string) \"y\" -> <missing>\n" +
+				".Any: (int) 1 -> (string) \"1\"\n" +
+				".Data: ([]uint8) [1 2 3] -> ([]uint8) [1 2 4]\n" +
+				".At: (time.Time) 2021-03-04T05:06:07Z -> (time.Time) 2021-03-04T05:06:08Z\n"},
+		{[]int(nil), []int{}, "([]int) <nil> -> ([]int) []\n"},
+		{map[int]bool{1: true}, map[int]bool{1: false}, "[1]: (bool) true -> (bool) false\n"},
+		{map[interface{}]int{int8(1): 1, "1": 1}, map[interface{}]int{int8(1): 2, "1": 1},
+			"[int8(1)]: (int) 1 -> (int) 2\n"},
+		{map[[2]string]int{{"a b", "c"}: 1}, map[[2]string]int{{"a", "b c"}: 1},
+			"[[2]string{\"a\", \"b c\"}]: <missing> -> (int) 1\n" +
+				"[[2]string{\"a b\", \"c\"}]: (int) 1 -> <missing>\n"},
+		{(*int)(nil), new(int), "(*int) <nil> -> (*int) <*>0\n"},
+		{&cycle1, &cycle2, ""},
+		{&cycle1, &cycle3, ".Name: (string) \"a\" -> (string) \"b\"\n"},
+		{math.NaN(), math.NaN(), ""},
+		{math.NaN(), 1.0, "(float64) NaN -> (float64) 1\n"},
+		{complex(math.NaN(), 1), complex(math.NaN(), 1), ""},
+	}
+
+	cfg := spew.ConfigState{SortKeys: true}
+	for i, test := range tests {
+		s := cfg.Diff(test.a, test.b)
+		if s != test.want {
+			t.Errorf("Diff #%d\n got: %s want: %s", i, s, test.want)
+		}
+
+		buf := new(bytes.Buffer)
+		cfg.Fdiff(buf, test.a, test.b)
+		if buf.String() != test.want {
+			t.Errorf("Fdiff #%d\n got: %s want: %s", i, buf.String(), test.want)
+		}
+	}
+}
+
+// diffCredentials has fields which are skipped or redacted.
+type diffCredentials struct {
+	User     string
+	Password string `spew:"redact"`
+	Secret   string `spew:"-"`
+	Token    string
+}
+
+// TestDiffFields ensures skipped and redacted struct fields never show up in
+// the differences.
+func TestDiffFields(t *testing.T) {
+	cfg := spew.ConfigState{FieldRules: []spew.FieldRule{{Name: "token", Action: spew.FieldRedact}}}
+	a := diffCredentials{User: "u", Password: "pw", Secret: "s", Token: "t"}
+
+	tests := []diffTest{
+		{a, diffCredentials{User: "u", Password: "pw", Secret: "other", Token: "t"}, ""},
+		{a, diffCredentials{User: "v", Password: "", Secret: "", Token: "t2"},
+			".User: (string) \"u\" -> (string) \"v\"\n" +
+				".Password: <redacted> -> <redacted>\n" +
+				".Token: <redacted> -> <redacted>\n"},
+	}
+
+	for i, test := range tests {
+		if s := cfg.Diff(test.a, test.b); s != test.want {
+			t.Errorf("DiffFields #%d\n got: %s want: %s", i, s, test.want)
+		}
+	}
+}
+
+// TestDiffSortedKeys ensures map keys are reported in sorted order even if
+// SortKeys is not set.
+func TestDiffSortedKeys(t *testing.T) {
+	a := map[string]int{"c": 1, "a": 1, "d": 1, "b": 1}
+	b := map[string]int{"c": 2, "a": 2, "d": 2, "b": 2}
+	want := "[\"a\"]: (int) 1 -> (int) 2\n" +
+		"[\"b\"]: (int) 1 -> (int) 2\n" +
+		"[\"c\"]: (int) 1 -> (int) 2\n" +
+		"[\"d\"]: (int) 1 -> (int) 2\n"
+
+	cfg := spew.ConfigState{}
+	for i := 0; i < 10; i++ {
+		if s := cfg.Diff(a, b); s != want {
+			t.Fatalf("DiffSortedKeys run %d\n got: %s want: %s", i, s, want)
+		}
+	}
+}
+
+// TestDiffNaNKeys ensures Diff pairs the entries of map keys which are not
+// equal to themselves, such as NaN, instead of reporting them as missing.
+func TestDiffNaNKeys(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		a, b interface{}
+		want string
+	}{
+		{map[float64]int{nan: 1}, map[float64]int{nan: 1}, ""},
+		{map[float64]int{nan: 1, nan: 2}, map[float64]int{nan: 2, nan: 1}, ""},
+		{map[float64]int{nan: 1}, map[float64]int{nan: 2}, "[math.NaN()]: (int) 1 -> (int) 2\n"},
+		{map[float64]int{nan: 1, nan: 2, nan: 3}, map[float64]int{nan: 5, nan: 4, nan: 3},
+			"[math.NaN()]: (int) 1 -> (int) 4\n[math.NaN()]: (int) 2 -> (int) 5\n"},
+		{map[float64]int{nan: 1, 1: 1}, map[float64]int{1: 1}, "[math.NaN()]: (int) 1 -> <missing>\n"},
+		{map[float64]int{}, map[float64]int{nan: 3}, "[math.NaN()]: <missing> -> (int) 3\n"},
+	}
+
+	for i, test := range tests {
+		// The entries of NaN keys are iterated in random order, so the
+		// output must not depend on it.
+		for run := 0; run < 10; run++ {
+			if s := spew.Diff(test.a, test.b); s != test.want {
+				t.Errorf("DiffNaNKeys #%d\n got: %s want: %s", i, s, test.want)
+				break
+			}
+		}
+	}
+}
+
+// diffConst is displayed the same regardless of its value.
+type diffConst struct {
+	n int
+}
+
+func (diffConst) String() string {
+	return "const"
+}
+
+// TestDiffConfig ensures limits, methods and pointer references of the
+// configuration never hide differences.
+func TestDiffConfig(t *testing.T) {
+	one, two := 1, 2
+	tests := []struct {
+		cfg  *spew.ConfigState
+		a, b interface{}
+		want string
+	}{
+		{&spew.ConfigState{MaxElements: 1}, []int{1, 2}, []int{1, 3}, "[1]: (int) 2 -> (int) 3\n"},
+		{&spew.ConfigState{MaxDepth: 1}, [][]int{{1}}, [][]int{{2}}, "[0][0]: (int) 1 -> (int) 2\n"},
+		{&spew.ConfigState{MaxStringLen: 1}, "ab", "ac", "(string) \"ab\" -> (string) \"ac\"\n"},
+		{&spew.ConfigState{MaxBytes: 1}, []byte{1, 2}, []byte{1, 3},
+			"([]uint8) [1 2] -> ([]uint8) [1 3]\n"},
+		{&spew.ConfigState{PointerIDs: true}, []*int{&one, &one}, []*int{&one, &two},
+			"[1]: (int) 1 -> (int) 2\n"},
+		{&spew.ConfigState{DetectSharedPointers: true}, []*int{&one, &one}, []*int{&one, &two},
+			"[1]: (int) 1 -> (int) 2\n"},
+		{&spew.ConfigState{}, diffConst{1}, diffConst{2}, ".n: (int) 1 -> (int) 2\n"},
+	}
+
+	for i, test := range tests {
+		if s := test.cfg.Diff(test.a, test.b); s != test.want {
+			t.Errorf("DiffConfig #%d\n got: %s want: %s", i, s, test.want)
+		}
+	}
+}
+
+// TestDiffHighlight ensures the old and new values are colorized.
+func TestDiffHighlight(t *testing.T) {
+	cfg := spew.ConfigState{
+		HighlightValues: true,
+		ColorMode:       spew.ColorAlways,
+		Theme: spew.Theme{
+			spew.TDiffRemoved: tagPrinter("old"),
+			spew.TDiffAdded:   tagPrinter("new"),
+			spew.TInteger:     tagPrinter("int"),
+		},
+	}
+
+	s := cfg.Diff(map[string]int{"a": 1}, map[string]int{"a": 2})
+	want := "[\"a\"]: <old>(int) 1</old> -> <new>(int) 2</new>\n"
+	if s != want {
+		t.Errorf("DiffHighlight\n got: %s want: %s", s, want)
+	}
+}
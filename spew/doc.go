@@ -31,6 +31,8 @@ printing facilities for Go data types are as follows:
 	* Byte arrays and slices are dumped like the hexdump -C command which
 	  includes offsets, byte values in hex, and ASCII output (only when using
 	  Dump style)
+	* time.Time, time.Duration and time.Location values are displayed in a
+	  human readable form (RFC3339 for time.Time) instead of their internals
 
 There are two different approaches spew allows for dumping Go data structures:
 
@@ -82,8 +84,25 @@ The following configuration options are available:
 		Maximum number of levels to descend into nested data structures.
 		There is no limit by default.
 
+	* MaxElements
+		Maximum number of elements of arrays, slices and maps to display.
+		There is no limit by default.
+
+	* MaxStringLen
+		Maximum number of bytes of strings to display.  There is no limit
+		by default.
+
+	* MaxBytes
+		Maximum number of bytes to hex dump.  Only the first and last
+		bytes of longer byte arrays and slices are dumped.  There is no
+		limit by default.
+
+	* MaxOutputBytes
+		Maximum number of bytes a single Dump or Formatter invocation
+		outputs.  There is no limit by default.
+
 	* DisableMethods
-		Disables invocation of error and Stringer interface methods.
+		Disables invocation of error, Stringer and SpewDumper interface methods.
 		Method invocation is enabled by default.
 
 	* DisablePointerMethods
@@ -95,6 +114,24 @@ The following configuration options are available:
 		DisablePointerAddresses specifies whether to disable the printing of
 		pointer addresses. This is useful when diffing data structures in tests.
 
+	* PointerIDs
+		Displays ordinals such as &1 instead of pointer addresses and
+		later pointers to the same target as -> &1, so shared data is
+		visible while the output stays the same across runs.
+
+	* DetectSharedPointers
+		Displays later pointers to an already displayed target as a
+		reference to the path of its first appearance, e.g.
+		<same as .Nodes[0].Parent>, instead of displaying it again.
+
+	* AnnotatePaths
+		Displays the Go expression each nested value is accessed by,
+		e.g. // v.Nested.Bytes, after the value in Dump style output.
+
+	* PathRoot
+		The name of the variable the expressions of AnnotatePaths start
+		with.  Defaults to v.
+
 	* DisableCapacities
 		DisableCapacities specifies whether to disable the printing of
 		capacities for arrays, slices, maps and channels. This is useful when
@@ -119,8 +156,38 @@ The following configuration options are available:
 		considered if SortKeys is true.
 
 	* HighlightValues
-		When true, values in dumps are highlighted using colours/colors
-		suitable for ANSI-compatible displays.
+		When true, values in dumps and in the output of the custom
+		Formatter are highlighted using colours/colors suitable for
+		ANSI-compatible displays.
+
+	* ColorMode
+		Specifies when colors are emitted if HighlightValues is enabled.
+		ColorAuto (the default) only emits colors when writing to a
+		terminal and honors the NO_COLOR, FORCE_COLOR, TERM and COLORTERM
+		environment variables.  ColorAlways and ColorNever override the
+		detection.  Colors are downsampled to what the terminal supports.
+
+	* Theme
+		Specifies the colors used when HighlightValues is enabled.
+		DefaultTheme is used by default.  LightTheme, SolarizedTheme,
+		MonochromeBoldTheme and HighContrastTheme are predefined as well,
+		and Theme.With derives a custom theme from an existing one.
+
+	* FieldRules
+		Specifies struct fields which are skipped or displayed as
+		<redacted>, in addition to fields tagged with `spew:"-"` or
+		`spew:"redact"`.  Rules match field names, types and paths.
+
+	* HexDumpOptions
+		Controls the layout of hex dumps, such as the number of bytes per
+		line, their grouping, the offsets and the character column, and
+		whether runs of identical lines are collapsed into a "*" line.
+		The layout of the hexdump -C command is used by default.
+
+	* JSONHexDump
+		Specifies whether JSON output includes a hexdump -C style dump of
+		byte arrays and slices in addition to their base64 encoded
+		contents.
 
 Dump Usage
 
@@ -137,6 +204,21 @@ A third option is to call spew.Sdump to get the formatted output as a string:
 
 	str := spew.Sdump(myVar1, myVar2, ...)
 
+To bound the time a dump may take, e.g. because of slow Stringer methods or
+huge data structures, call spew.FdumpContext or spew.SdumpContext.  They stop
+once the context is done and mark the output with <canceled: ...>:
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	str := spew.SdumpContext(ctx, myVar1, myVar2, ...)
+
+To dump only a part of a large value, call spew.DumpPath with a path query of
+field names, indices, map keys, [*] wildcards and ..Name recursive descent.
+Each selected value is prefixed with the path it is reached by:
+
+	err := spew.DumpPath(req, ".Spec.Containers[2].Env")
+	str, err := spew.SdumpPath(req, "..Env")
+
 Sample Dump Output
 
 See the Dump example for details on the setup of the types and variables being
@@ -160,6 +242,107 @@ command as shown.
 	 00000020  31 32                                             |12|
 	}
 
+The layout of the hex dumps can be changed with the HexDumpOptions option.
+The same options are accepted by spew.HexDumpWith and spew.NewDumper to hex
+dump data directly.
+spew.ParseHexDump turns such hex dumps, e.g. copied from logs, back into the
+original bytes.
+
+Likewise, spew.ParseDump reads captured Dump output, colorized or not, back
+into a tree of spew.Node values holding the type, kind, value and children of
+each dumped value, so tools can inspect and re-render it:
+
+	nodes, err := spew.ParseDump(strings.NewReader(capturedDump))
+
+The same tree is built from live values by spew.Walk, which follows pointers,
+detects circular references and calls methods exactly like Dump does.  Dump
+and the custom Formatter are renderers over it, so new output formats only
+need to render the nodes:
+
+	node := spew.Walk(&spew.Config, myVar)
+
+JSON Usage
+
+To describe a value as JSON, for example to ship it to a log pipeline which
+only indexes JSON, call spew.Sjson or spew.Fjson:
+
+	str := spew.Sjson(myVar1, myVar2, ...)
+	err := spew.Fjson(someWriter, myVar1, myVar2, ...)
+
+Each argument becomes a single line JSON document holding the same
+information Dump displays, such as types, len/cap, pointer chains and the
+results of Stringer/error interfaces:
+
+	{"type":"*main.Bar","pointers":["0xf84002e210"],"fields":[...]}
+
+See Sjson for the full list of members.
+
+Go Literal Usage
+
+To turn a captured value into Go source code, for example to paste a value
+seen in production into a regression test, call spew.Sliteral or
+spew.Fliteral:
+
+	str := spew.Sliteral(myVar1, myVar2, ...)
+
+Each argument becomes a compilable Go composite literal such as:
+
+	main.Foo{ExportedField: map[interface {}]interface {}{"one": true}}
+
+Pointers which are referenced more than once, including circular references,
+are declared as helper variables.  See Sliteral for details.
+
+Diff Usage
+
+To compare two values call spew.Diff, which walks both of them the same way
+Dump does and returns one line for every path at which they differ:
+
+	str := spew.Diff(want, got)
+
+	.Map["Foo"]: (int) 15 -> (int) 16
+	.List[2]: (string) "c" -> <missing>
+
+Pointer addresses never cause a difference, NaN equals NaN, also as a map
+key, and map keys are always reported in sorted order.  An empty string means
+both values are equal.  Use spew.Fdiff to write the differences to an
+arbitrary io.Writer.
+
+Type Formatters
+
+Types which are not rendered usefully by default, e.g. because they are
+defined by third-party packages, can be given a custom rendering with
+spew.RegisterFormatter.  The registered function writes the value through a
+spew.Printer, either as a single value or as a block of fields and elements:
+
+	spew.RegisterFormatter(reflect.TypeOf(decimal.Decimal{}),
+		func(p *spew.Printer, v reflect.Value) {
+			d := v.Interface().(decimal.Decimal)
+			p.Text(spew.TFloat, d.String())
+		})
+
+	spew.RegisterFormatter(reflect.TypeOf(pb.User{}),
+		func(p *spew.Printer, v reflect.Value) {
+			u := v.Interface().(pb.User)
+			p.Field("Name", u.GetName())
+			p.Field("Groups", u.GetGroups())
+		})
+
+Nested values are indented, colorized and checked for circular references and
+the maximum depth just like all other values.  Registered formatters apply to
+both Dump style output and the custom Formatter.
+
+Types can also control their own output by implementing spew.SpewDumper, and
+optionally spew.SpewFormatter for the more compact output of the custom
+Formatter:
+
+	func (u User) SpewDump(p *spew.Printer) {
+		p.Field("Name", u.Name)
+		p.Field("Groups", u.Groups)
+	}
+
+These methods are called before the error and Stringer interfaces and are
+subject to the DisableMethods and DisablePointerMethods options as well.
+
 Custom Formatter
 
 Spew provides a custom formatter that implements the fmt.Formatter interface
@@ -18,7 +18,7 @@ package spew
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"io"
 	"os"
 	"reflect"
@@ -46,101 +46,142 @@ var (
 	cUint8tCharRE = regexp.MustCompile(`^.*\._Ctype_uint8_t$`)
 )
 
-// dumpState contains information about the state of a dump operation.
+// dumpState contains information about the state of a dump operation.  It
+// renders the Nodes produced by its walkState.
 type dumpState struct {
 	w                io.Writer
 	depth            int
-	pointers         map[uintptr]int
 	ignoreNextType   bool
 	ignoreNextIndent bool
 	cs               *ConfigState
+
+	// walker walks the value dumped.  It is created on first use if not
+	// set.
+	walker *walkState
+
+	// cw is the colorWriter w writes through, if any.
+	cw *colorWriter
+
+	// lw is the limitWriter enforcing MaxOutputBytes, if any.
+	lw *limitWriter
+
+	// ctx is the context of the dump, if any.  Once it is done, err is set
+	// and the value is not walked any further.
+	ctx context.Context
+	err error
+
+	// nw writes the annotations of AnnotatePaths.  It is created on first
+	// use and w writes through it from then on.
+	nw *noteWriter
 }
 
-// indent performs indentation according to the depth level and cs.Indent
-// option.
-func (d *dumpState) indent() {
-	if d.ignoreNextIndent {
-		d.ignoreNextIndent = false
-		return
+// noteWriter writes the pending note, the annotation of the value written
+// last, as a comment in front of the next newline.
+type noteWriter struct {
+	w    io.Writer
+	cw   *colorWriter
+	note string
+}
+
+func (w *noteWriter) Write(p []byte) (n int, err error) {
+	i := bytes.IndexByte(p, '\n')
+	if w.note == "" || i < 0 {
+		return w.w.Write(p)
 	}
-	d.w.Write(bytes.Repeat([]byte(d.cs.Indent), d.depth))
+
+	if n, err = w.w.Write(p[:i]); err != nil {
+		return n, err
+	}
+
+	// The comment must not change the color of the text around it.
+	var col ColorPrinter
+	if w.cw != nil {
+		col = w.cw.col
+	}
+	w.cw.rawColor(TPath)
+	_, err = w.w.Write([]byte(" // " + w.note))
+	if w.cw != nil {
+		w.cw.col = col
+	}
+	w.note = ""
+	if err != nil {
+		return i, err
+	}
+
+	m, err := w.w.Write(p[i:])
+	return i + m, err
 }
 
-// unpackValue returns values inside of non-nil interfaces when possible.
-// This is useful for data types like structs, arrays, slices, and maps which
-// can contain varying types packed inside an interface.
-func (d *dumpState) unpackValue(v reflect.Value) reflect.Value {
-	if v.Kind() == reflect.Interface && !v.IsNil() {
-		v = v.Elem()
+// annotate sets the note written in front of the next newline to expr.
+func (d *dumpState) annotate(expr string) {
+	if d.nw == nil {
+		d.nw = &noteWriter{w: d.w, cw: d.cw}
+		d.w = d.nw
 	}
-	return v
+	d.nw.note = expr
 }
 
-// dumpPtr handles formatting of pointers by indirecting them as necessary.
-func (d *dumpState) dumpPtr(v reflect.Value) {
-	// Remove pointers at or below the current depth from map used to detect
-	// circular refs.
-	for k, depth := range d.pointers {
-		if depth >= d.depth {
-			delete(d.pointers, k)
-		}
+// canceled returns whether the context of the dump is done.  The first time
+// this is detected, the canceled marker is written, indented if newline is
+// set and followed by a newline if newline is set.
+func (d *dumpState) canceled(newline bool) bool {
+	if d.err != nil {
+		return true
+	}
+	if d.ctx == nil {
+		return false
 	}
 
-	// Keep list of all dereferenced pointers to show later.
-	pointerChain := make([]uintptr, 0)
+	err := d.ctx.Err()
+	if err == nil {
+		return false
+	}
 
-	// Figure out how many levels of indirection there are by dereferencing
-	// pointers and unpacking interfaces down the chain while detecting circular
-	// references.
-	nilFound := false
-	cycleFound := false
-	indirects := 0
-	ve := v
-	for ve.Kind() == reflect.Ptr {
-		if ve.IsNil() {
-			nilFound = true
-			break
-		}
-		indirects++
-		addr := ve.Pointer()
-		pointerChain = append(pointerChain, addr)
-		if pd, ok := d.pointers[addr]; ok && pd < d.depth {
-			cycleFound = true
-			indirects--
-			break
-		}
-		d.pointers[addr] = d.depth
+	d.indent()
+	d.writeCanceled(err)
+	if newline {
+		d.w.Write(newlineBytes)
+	}
 
-		ve = ve.Elem()
-		if ve.Kind() == reflect.Interface {
-			if ve.IsNil() {
-				nilFound = true
-				break
-			}
-			ve = ve.Elem()
-		}
+	return true
+}
+
+// writeCanceled records that the context of the dump is done with err and
+// writes the canceled marker.
+func (d *dumpState) writeCanceled(err error) {
+	d.err = err
+	d.writeColored(TTruncated, canceledMarker(err))
+}
+
+// indent performs indentation according to the depth level and cs.Indent
+// option.
+func (d *dumpState) indent() {
+	if d.ignoreNextIndent {
+		d.ignoreNextIndent = false
+		return
 	}
+	d.w.Write(bytes.Repeat([]byte(d.cs.Indent), d.depth))
+}
 
+// renderPtr handles formatting of pointers, which are described by the node
+// of the value at the end of the chain.
+func (d *dumpState) renderPtr(n *Node) {
 	// Display type information.
 	d.w.Write(openParenBytes)
-	if d.cs.HighlightValues {
-		colorPtr(ve.Type().String())
-	}
-	d.w.Write(bytes.Repeat(asteriskBytes, indirects))
-	d.w.Write([]byte(ve.Type().String()))
-	stopColor()
+	d.cw.colorPtr(n.typ.String())
+	d.w.Write(bytes.Repeat(asteriskBytes, n.Indirects))
+	d.w.Write([]byte(n.typ.String()))
+	d.cw.stopColor()
 	d.w.Write(closeParenBytes)
 
 	// Display pointer information.
-	if !d.cs.DisablePointerAddresses && len(pointerChain) > 0 {
+	if (d.cs.PointerIDs || !d.cs.DisablePointerAddresses) && len(n.Addrs) > 0 {
 		d.w.Write(openParenBytes)
-		for i, addr := range pointerChain {
+		for i, addr := range n.Addrs {
 			if i > 0 {
 				d.w.Write(pointerChainBytes)
 			}
-			rawColor(TTAddress)
-			printHexPtr(d.w, addr)
-			stopColor()
+			d.writeColored(TTAddress, []byte(addr))
 		}
 		d.w.Write(closeParenBytes)
 	}
@@ -148,30 +189,52 @@ func (d *dumpState) dumpPtr(v reflect.Value) {
 	// Display dereferenced value.
 	d.w.Write(openParenBytes)
 	switch {
-	case nilFound:
-		rawColor(TNil)
-		d.w.Write(nilAngleBytes)
-		stopColor()
+	case n.Nil:
+		d.writeColored(TNil, nilAngleBytes)
 
-	case cycleFound:
-		d.w.Write(circularBytes)
+	case strings.HasPrefix(n.Ref, string(ampersandBytes)):
+		d.cw.rawColor(TCircular)
+		d.w.Write(pointerRefBytes)
+		d.w.Write([]byte(n.Ref))
+		d.cw.stopColor()
+
+	case n.Ref != "":
+		d.cw.rawColor(TCircular)
+		printSharedPath(d.w, n.Ref)
+		d.cw.stopColor()
+
+	case n.Circular:
+		d.writeColored(TCircular, circularBytes)
 
 	default:
 		d.ignoreNextType = true
-		d.dump(ve)
+		d.renderValue(n)
 	}
 	d.w.Write(closeParenBytes)
 }
 
-// dumpSlice handles formatting of arrays and slices.  Byte (uint8 under
-// reflection) arrays and slices are dumped in hexdump -C fashion.
-func (d *dumpState) dumpSlice(v reflect.Value) {
-	// Determine whether this type should be hex dumped or not.  Also,
-	// for types which should be hexdumped, try to use the underlying data
-	// first, then fall back to trying to convert them to a uint8 slice.
-	var buf []uint8
+// writeColored writes b in the color of type t.
+func (d *dumpState) writeColored(t Type, b []byte) {
+	d.cw.rawColor(t)
+	d.w.Write(b)
+	d.cw.stopColor()
+}
+
+// writeMaxDepth writes the marker for a reached maximum depth.
+func (d *dumpState) writeMaxDepth() {
+	d.indent()
+	d.cw.rawColor(TMaxDepth)
+	d.w.Write(maxDepthBytes)
+	d.cw.stopColor()
+	d.w.Write(newlineBytes)
+}
+
+// hexDumpBytes returns the contents of the array or slice v if it should be
+// hex dumped, that is if it holds bytes (uint8 under reflection) or cgo
+// chars.  For types which should be hexdumped, it tries to use the underlying
+// data first, then falls back to trying to convert them to a uint8 slice.
+func hexDumpBytes(v reflect.Value) (buf []uint8, doHexDump bool) {
 	doConvert := false
-	doHexDump := false
 	numEntries := v.Len()
 	if numEntries > 0 {
 		vt := v.Index(0).Type()
@@ -230,51 +293,110 @@ func (d *dumpState) dumpSlice(v reflect.Value) {
 		}
 	}
 
-	// TODO: colorize
-	//		Red:	Non-printable ASCII characters
-	//		Orange: Printable (Alphabetic) Characters
-	//		Yellow: Base 10 Numerical Digits
-	//		Green:  ASCII Whitespace Characters
-	//		Purple: Punctuation Characters
-	//		Gray:	NUL byte (00)
-
-	// Hexdump the entire slice as needed.
-	if doHexDump {
-		indent := strings.Repeat(d.cs.Indent, d.depth)
-		str := indent + HexDump(buf, d.cs.HighlightHex)
-		str = strings.Replace(str, "\n", "\n"+indent, -1)
-		str = strings.TrimRight(str, d.cs.Indent)
-		d.w.Write([]byte(str))
+	return buf, doHexDump
+}
+
+// writeHexDump writes the bytes of an array or slice in hexdump -C fashion.
+func (d *dumpState) writeHexDump(n *Node) {
+	opts := d.cs.HexDumpOptions
+	opts.Theme = nil
+	if d.cw != nil && d.cs.HighlightHex {
+		opts.Theme = d.cw.theme
+	}
+
+	indent := strings.Repeat(d.cs.Indent, d.depth)
+	str := indent + hexDumpWindows(n.Bytes, d.cs.MaxBytes, opts)
+	str = strings.Replace(str, "\n", "\n"+indent, -1)
+	str = strings.TrimRight(str, d.cs.Indent)
+	d.w.Write([]byte(str))
+}
+
+// separate writes the separator following a child of a block, which is a
+// newline for the last one.
+func (d *dumpState) separate(last bool) {
+	if !last && d.err == nil {
+		d.w.Write(commaNewlineBytes)
+	} else {
+		d.w.Write(newlineBytes)
+	}
+}
+
+// stopped returns whether the output is not written any further, because it
+// is truncated or the context of the dump is done.
+func (d *dumpState) stopped() bool {
+	return d.err != nil || limitFull(d.lw)
+}
+
+// writeMore writes the marker for n omitted elements on a line of its own,
+// unless n is 0.
+func (d *dumpState) writeMore(n int) {
+	if n == 0 {
 		return
 	}
 
-	// Recursively call dump for each item.
-	for i := 0; i < numEntries; i++ {
-		d.dump(d.unpackValue(v.Index(i)))
-		if i < (numEntries - 1) {
-			d.w.Write(commaNewlineBytes)
-		} else {
-			d.w.Write(newlineBytes)
+	d.indent()
+	d.writeColored(TTruncated, moreMarker(n, moreSuffix))
+	d.w.Write(newlineBytes)
+}
+
+// dump is the main workhorse for dumping a value.  It walks the passed
+// reflect value and renders the resulting node.  Circular data structures are
+// detected and handled properly by the walk.
+func (d *dumpState) dump(v reflect.Value) {
+	// Stop walking the value once the output is truncated or the context
+	// is done.
+	if limitFull(d.lw) || d.canceled(false) {
+		return
+	}
+
+	d.render(d.walk().node(v))
+}
+
+// walk returns the walker of the dump, which is created on first use.
+func (d *dumpState) walk() *walkState {
+	if d.walker == nil {
+		d.walker = newWalkState(d.ctx, d.cs, false)
+		d.walker.more = func() bool {
+			return !limitFull(d.lw) && !d.canceled(true)
 		}
 	}
+
+	return d.walker
 }
 
-// dump is the main workhorse for dumping a value.  It uses the passed reflect
-// value to figure out what kind of object we are dealing with and formats it
-// appropriately.  It is a recursive function, however circular data structures
-// are detected and handled properly.
-func (d *dumpState) dump(v reflect.Value) {
+// render writes the node n and walks its children while writing them.
+func (d *dumpState) render(n *Node) {
+	// Stop once the output is truncated or the context is done, unless the
+	// context was found to be done while walking n, which is displayed in
+	// place of its value.
+	if limitFull(d.lw) || n.err == nil && d.canceled(false) {
+		return
+	}
+
+	if n.expr != "" {
+		d.annotate(n.expr)
+	}
+
 	// Handle invalid reflect values immediately.
-	kind := v.Kind()
-	if kind == reflect.Invalid {
+	if n.Invalid {
 		d.w.Write(invalidAngleBytes)
 		return
 	}
 
 	// Handle pointers specially.
-	if kind == reflect.Ptr {
+	if n.isPtr() {
 		d.indent()
-		d.dumpPtr(v)
+		d.renderPtr(n)
+		return
+	}
+
+	d.renderValue(n)
+}
+
+// renderValue writes the value described by n, which is the value pointed to
+// for pointers.
+func (d *dumpState) renderValue(n *Node) {
+	if limitFull(d.lw) {
 		return
 	}
 
@@ -282,15 +404,9 @@ func (d *dumpState) dump(v reflect.Value) {
 	if !d.ignoreNextType {
 		d.indent()
 		d.w.Write(openParenBytes)
-
-		typeStr := v.Type()
-		if d.cs.HighlightValues {
-			typeColor(typeStr)
-		}
-
-		d.w.Write([]byte(typeStr.String()))
-		stopColor()
-
+		d.cw.typeColor(n.typ)
+		d.w.Write([]byte(n.Type))
+		d.cw.stopColor()
 		d.w.Write(closeParenBytes)
 		d.w.Write(spaceBytes)
 	}
@@ -298,201 +414,243 @@ func (d *dumpState) dump(v reflect.Value) {
 
 	// Display length and capacity if the built-in len and cap functions
 	// work with the value's kind and the len/cap itself is non-zero.
-	valueLen, valueCap := 0, 0
-	switch v.Kind() {
-	case reflect.Array, reflect.Slice, reflect.Chan:
-		valueLen, valueCap = v.Len(), v.Cap()
-	case reflect.Map, reflect.String:
-		valueLen = v.Len()
-	}
+	valueLen, valueCap := n.Len, n.Cap
 	if valueLen != 0 || !d.cs.DisableCapacities && valueCap != 0 {
 		d.w.Write(openParenBytes)
 		if valueLen != 0 {
-			specialColor(TLen)
+			d.cw.specialColor(TLen)
 			d.w.Write(lenEqualsBytes)
 			printInt(d.w, int64(valueLen), 10)
 		}
 
 		if !d.cs.DisableCapacities && valueCap != 0 {
 			if valueLen != 0 {
-				stopColor()
+				d.cw.stopColor()
 				d.w.Write(spaceBytes)
 			}
 
-			specialColor(TCap)
+			d.cw.specialColor(TCap)
 			d.w.Write(capEqualsBytes)
 			printInt(d.w, int64(valueCap), 10)
 		}
 
-		stopColor()
+		d.cw.stopColor()
 		d.w.Write(closeParenBytes)
 		d.w.Write(spaceBytes)
 	}
 
-	// Call Stringer/error interfaces if they exist and the handle methods flag
-	// is enabled
-	if !d.cs.DisableMethods {
-		if (kind != reflect.Invalid) && (kind != reflect.Interface) {
-			if handled := handleMethods(d.cs, d.w, v); handled {
-				return
-			}
-		}
+	// Let custom formatters and SpewDumper implementations render the
+	// value.
+	if n.custom != nil {
+		d.renderCustom(n)
+		return
 	}
 
-	color(v)
-
-	switch kind {
-	case reflect.Invalid:
-		// Do nothing.  We should never get here since invalid has already
-		// been handled above.
-
-	case reflect.Bool:
-		printBool(d.w, v.Bool())
-
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		printInt(d.w, v.Int(), 10)
-
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		printUint(d.w, v.Uint(), 10)
-
-	case reflect.Float32:
-		printFloat(d.w, v.Float(), 32)
-
-	case reflect.Float64:
-		printFloat(d.w, v.Float(), 64)
+	// Display time values in a human readable form rather than their
+	// internal representation.
+	if n.time {
+		d.writeColored(TDate, []byte(n.Value))
+		return
+	}
 
-	case reflect.Complex64:
-		printComplex(d.w, v.Complex(), 32)
+	// Display the result of the Stringer/error interfaces, if called.
+	if n.err != nil {
+		d.cw.stopColor()
+		d.writeCanceled(n.err)
+		return
+	}
+	if n.call != nil && n.call.write(d.cs, d.w) {
+		return
+	}
 
-	case reflect.Complex128:
-		printComplex(d.w, v.Complex(), 64)
+	d.cw.color(n)
 
-	case reflect.Slice:
-		if v.IsNil() {
+	switch n.Kind {
+	case reflect.Slice, reflect.Array:
+		if n.Nil {
 			d.w.Write(nilAngleBytes)
 			break
 		}
-		fallthrough
 
-	case reflect.Array:
 		d.w.Write(openBraceNewlineBytes)
 		d.depth++
-		if (d.cs.MaxDepth != 0) && (d.depth > d.cs.MaxDepth) {
-			d.indent()
-			d.w.Write(maxNewlineBytes)
-		} else {
-			d.dumpSlice(v)
+		d.walker.children(n, d.renderChild)
+		switch {
+		case n.MaxDepth:
+			d.writeMaxDepth()
+		case n.Bytes != nil:
+			d.writeHexDump(n)
+		default:
+			d.writeMore(n.More)
 		}
 		d.depth--
 		d.indent()
 		d.w.Write(closeBraceBytes)
 
 	case reflect.String:
-		d.w.Write([]byte(strconv.Quote(v.String())))
-
-	case reflect.Interface:
-		// The only time we should get here is for nil interfaces due to
-		// unpackValue calls.
-		if v.IsNil() {
-			d.w.Write(nilAngleBytes)
+		d.w.Write([]byte(strconv.Quote(n.Value)))
+		if n.More > 0 {
+			d.cw.stopColor()
+			d.w.Write(spaceBytes)
+			d.writeColored(TTruncated, moreMarker(n.More, moreBytesSuffix))
 		}
 
-	case reflect.Ptr:
-		// Do nothing.  We should never get here since pointers have already
-		// been handled above.
-
 	case reflect.Map:
 		// nil maps should be indicated as different than empty maps
-		if v.IsNil() {
+		if n.Nil {
 			d.w.Write(nilAngleBytes)
 			break
 		}
 
-		d.w.Write(openBraceNewlineBytes)
+		d.writeColored(TMap, openBraceBytes)
+		d.w.Write(newlineBytes)
 		d.depth++
-		if (d.cs.MaxDepth != 0) && (d.depth > d.cs.MaxDepth) {
-			d.indent()
-			d.w.Write(maxNewlineBytes)
+		d.walker.children(n, d.renderChild)
+		if n.MaxDepth {
+			d.writeMaxDepth()
 		} else {
-			numEntries := v.Len()
-			keys := v.MapKeys()
-			if d.cs.SortKeys {
-				sortValues(keys, d.cs)
-			}
-			for i, key := range keys {
-				d.dump(d.unpackValue(key))
-				d.w.Write(colonSpaceBytes)
-				d.ignoreNextIndent = true
-				d.dump(d.unpackValue(v.MapIndex(key)))
-				if i < (numEntries - 1) {
-					d.w.Write(commaNewlineBytes)
-				} else {
-					d.w.Write(newlineBytes)
-				}
-			}
+			d.writeMore(n.More)
 		}
 		d.depth--
 		d.indent()
-		d.w.Write(closeBraceBytes)
+		d.writeColored(TMap, closeBraceBytes)
 
 	case reflect.Struct:
-		d.w.Write(openBraceNewlineBytes)
+		d.writeColored(TStruct, openBraceBytes)
+		d.w.Write(newlineBytes)
 		d.depth++
-		if (d.cs.MaxDepth != 0) && (d.depth > d.cs.MaxDepth) {
-			d.indent()
-			d.w.Write(maxNewlineBytes)
-		} else {
-			vt := v.Type()
-			numFields := v.NumField()
-			for i := 0; i < numFields; i++ {
-				d.indent()
-				vtf := vt.Field(i)
-				d.w.Write([]byte(vtf.Name))
-				d.w.Write(colonSpaceBytes)
-				d.ignoreNextIndent = true
-				d.dump(d.unpackValue(v.Field(i)))
-				if i < (numFields - 1) {
-					d.w.Write(commaNewlineBytes)
-				} else {
-					d.w.Write(newlineBytes)
-				}
-			}
+		d.walker.children(n, d.renderChild)
+		if n.MaxDepth {
+			d.writeMaxDepth()
 		}
 		d.depth--
 		d.indent()
-		d.w.Write(closeBraceBytes)
-
-	case reflect.Uintptr:
-		printHexPtr(d.w, uintptr(v.Uint()))
+		d.writeColored(TStruct, closeBraceBytes)
 
-	case reflect.UnsafePointer, reflect.Chan, reflect.Func:
-		printHexPtr(d.w, v.Pointer())
+	case reflect.Interface:
+		// The only time we should get here is for nil interfaces.
+		if n.Nil {
+			d.w.Write(nilAngleBytes)
+		}
 
-	// There were not any other types at the time this code was written, but
-	// fall back to letting the default fmt package handle it in case any new
-	// types are added.
 	default:
-		if v.CanInterface() {
-			fmt.Fprintf(d.w, "%v", v.Interface())
+		d.w.Write([]byte(n.Value))
+	}
+
+	d.cw.stopColor()
+}
+
+// renderChild writes an element, map entry or struct field of the value
+// currently rendered.  It returns whether the output is written any further.
+func (d *dumpState) renderChild(p part) bool {
+	switch p.kind {
+	case partElem:
+		d.render(p.node)
+		d.separate(p.last)
+
+	case partKey:
+		d.render(p.node)
+		d.w.Write(colonSpaceBytes)
+
+	case partValue:
+		d.ignoreNextIndent = true
+		d.render(p.node)
+		d.separate(p.last)
+
+	case partField:
+		d.indent()
+		d.writeColored(TFieldName, []byte(p.node.Name))
+		d.w.Write(colonSpaceBytes)
+		if p.node.Redacted {
+			d.writeColored(TRedacted, redactedBytes)
 		} else {
-			fmt.Fprintf(d.w, "%v", v.String())
+			d.ignoreNextIndent = true
+			d.render(p.node)
 		}
+		d.separate(p.last)
 	}
 
-	stopColor()
+	return true
+}
+
+// renderCustom writes the value n as rendered by its custom formatter or
+// SpewDumper implementation, see Printer.  Blocks are always delimited by
+// braces.
+func (d *dumpState) renderCustom(n *Node) {
+	count := 0
+	d.walker.children(n, func(p part) bool {
+		switch p.kind {
+		case partText:
+			if p.colored {
+				d.writeColored(p.typ, []byte(p.text))
+			} else {
+				d.w.Write([]byte(p.text))
+			}
+
+		case partInline:
+			d.ignoreNextIndent = true
+			d.render(p.node)
+
+		case partOpen:
+			d.writeColored(TStruct, openBraceBytes)
+			d.w.Write(newlineBytes)
+			d.depth++
+			if n.MaxDepth {
+				d.writeMaxDepth()
+			}
+
+		case partField, partElem:
+			if count > 0 {
+				d.w.Write(commaNewlineBytes)
+			}
+			if p.kind == partField {
+				d.indent()
+				d.writeColored(TFieldName, []byte(p.node.Name))
+				d.w.Write(colonSpaceBytes)
+				d.ignoreNextIndent = true
+			}
+			d.render(p.node)
+			count++
+
+		case partClose:
+			if n.More > 0 {
+				if count > 0 {
+					d.w.Write(commaNewlineBytes)
+				}
+				d.indent()
+				d.writeColored(TTruncated, moreMarker(n.More, moreSuffix))
+				count++
+			}
+			if count > 0 {
+				d.w.Write(newlineBytes)
+			}
+			d.depth--
+			d.indent()
+			d.writeColored(TStruct, closeBraceBytes)
+		}
+
+		return !d.stopped()
+	})
 }
 
 // fdump is a helper function to consolidate the logic from the various public
-// methods which take varying writers and config states.
-func fdump(cs *ConfigState, w io.Writer, a ...interface{}) {
-	// initialize global colorWriter, if enabled
-	cWriter = &colorWriter{
-		origWriter:        w,
-		globalDisabled:    !cs.HighlightValues,
-		globalHexDisabled: !cs.HighlightHex,
+// methods which take varying contexts, writers and config states.  It returns
+// the error of ctx if it is done before all values are dumped.
+func fdump(ctx context.Context, cs *ConfigState, w io.Writer, a ...interface{}) error {
+	// The limit applies to the output of all values, so it is enforced
+	// beneath their colorWriters.
+	lvl := cs.colorLevel(w)
+	var lw *limitWriter
+	if cs.MaxOutputBytes > 0 {
+		lw = newLimitWriter(w, cs.MaxOutputBytes, lvl != levelNone, outputTruncatedDumpBytes)
+		w = lw
 	}
 
 	for _, arg := range a {
+		if limitFull(lw) {
+			break
+		}
+
 		if arg == nil {
 			w.Write(interfaceBytes)
 			w.Write(spaceBytes)
@@ -501,24 +659,45 @@ func fdump(cs *ConfigState, w io.Writer, a ...interface{}) {
 			continue
 		}
 
-		d := dumpState{w: cWriter, cs: cs}
-		d.pointers = make(map[uintptr]int)
+		cw := newColorWriter(w, cs, lvl)
+		d := dumpState{w: cw, cs: cs, cw: cw, lw: lw, ctx: ctx}
 		d.dump(reflect.ValueOf(arg))
 		d.w.Write(newlineBytes)
+		if d.err != nil {
+			return d.err
+		}
 	}
+
+	return nil
 }
 
 // Fdump formats and displays the passed arguments to io.Writer w.  It formats
 // exactly the same as Dump.
 func Fdump(w io.Writer, a ...interface{}) {
-	fdump(&Config, w, a...)
+	fdump(context.Background(), &Config, w, a...)
 }
 
 // Sdump returns a string with the passed arguments formatted exactly the same
 // as Dump.
 func Sdump(a ...interface{}) string {
 	var buf bytes.Buffer
-	fdump(&Config, &buf, a...)
+	fdump(context.Background(), &Config, &buf, a...)
+	return buf.String()
+}
+
+// FdumpContext is like Fdump, but stops dumping once ctx is done.  The values
+// dumped so far are completed with a "<canceled: deadline exceeded>" style
+// marker and the error of ctx is returned.  Stringer and error methods which do
+// not return in time are abandoned.
+func FdumpContext(ctx context.Context, w io.Writer, a ...interface{}) error {
+	return fdump(ctx, &Config, w, a...)
+}
+
+// SdumpContext is like Sdump, but stops dumping once ctx is done.  See
+// FdumpContext for details.
+func SdumpContext(ctx context.Context, a ...interface{}) string {
+	var buf bytes.Buffer
+	fdump(ctx, &Config, &buf, a...)
 	return buf.String()
 }
 
@@ -546,5 +725,5 @@ See Fdump if you would prefer dumping to an arbitrary io.Writer or Sdump to
 get the formatted result as a string.
 */
 func Dump(a ...interface{}) {
-	fdump(&Config, os.Stdout, a...)
+	fdump(context.Background(), &Config, os.Stdout, a...)
 }
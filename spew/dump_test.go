@@ -64,11 +64,16 @@ package spew_test
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"regexp"
+	"sync"
 	"testing"
+	"time"
 	"unsafe"
 
+	gcolor "github.com/gookit/color"
 	"github.com/l0nax/go-spew/spew"
+	"github.com/l0nax/go-spew/spew/spewtest"
 )
 
 // dumpTest is used to describe a test to be performed against the Dump method.
@@ -957,6 +962,49 @@ func addErrorDumpTests() {
 	addDumpTest(nv, "(*"+vt+")(<nil>)\n")
 }
 
+func addTimeDumpTests() {
+	// Time values are dumped in RFC3339 format.
+	v := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+	nv := (*time.Time)(nil)
+	pv := &v
+	vAddr := fmt.Sprintf("%p", pv)
+	pvAddr := fmt.Sprintf("%p", &pv)
+	vt := "time.Time"
+	vs := "2021-03-04T05:06:07.000000008Z"
+	addDumpTest(v, "("+vt+") "+vs+"\n")
+	addDumpTest(pv, "(*"+vt+")("+vAddr+")("+vs+")\n")
+	addDumpTest(&pv, "(**"+vt+")("+pvAddr+"->"+vAddr+")("+vs+")\n")
+	addDumpTest(nv, "(*"+vt+")(<nil>)\n")
+
+	// Durations are dumped like time.Duration.String.
+	v2 := 90 * time.Second
+	v2t := "time.Duration"
+	v2s := "1m30s"
+	addDumpTest(v2, "("+v2t+") "+v2s+"\n")
+
+	// Locations are dumped by their name.
+	v3 := time.UTC
+	v3Addr := fmt.Sprintf("%p", v3)
+	v3t := "time.Location"
+	v3s := "UTC"
+	addDumpTest(v3, "(*"+v3t+")("+v3Addr+")("+v3s+")\n")
+
+	// Time values in unexported struct fields.
+	type timeStruct struct {
+		t time.Time
+		d time.Duration
+	}
+	v4 := timeStruct{v, v2}
+	v4t := "spew_test.timeStruct"
+	v4s := "{\n t: (" + vt + ") " + vs + ",\n d: (" + v2t + ") " + v2s + "\n}"
+	if spew.UnsafeDisabled {
+		v4s = "{\n t: (" + vt + ") {\n  wall: (uint64) 8,\n" +
+			"  ext: (int64) 63750431167,\n  loc: (*time.Location)(<nil>)\n }," +
+			"\n d: (" + v2t + ") " + v2s + "\n}"
+	}
+	addDumpTest(v4, "("+v4t+") "+v4s+"\n")
+}
+
 // TestDump executes all of the tests described by dumpTests.
 func TestDump(t *testing.T) {
 	// Setup tests.
@@ -978,6 +1026,7 @@ func TestDump(t *testing.T) {
 	addCircularDumpTests()
 	addPanicDumpTests()
 	addErrorDumpTests()
+	addTimeDumpTests()
 	addCgoDumpTests()
 
 	t.Logf("Running %d tests", len(dumpTests))
@@ -1039,10 +1088,113 @@ func TestDumpSortedKeys(t *testing.T) {
 	if s != expected {
 		t.Errorf("Sorted keys mismatch:\n  %v %v", s, expected)
 	}
+
+	// NaN keys can not be looked up, their values are shown nonetheless.
+	s = cfg.Sdump(map[float64]int{math.NaN(): 7, math.NaN(): 7})
+	expected = "(map[float64]int) (len=2) {\n" +
+		"(float64) NaN: (int) 7,\n" +
+		"(float64) NaN: (int) 7\n" +
+		"}\n"
+	if s != expected {
+		t.Errorf("Sorted keys mismatch:\n  %v %v", s, expected)
+	}
+}
+
+func TestDumpAnnotatePaths(t *testing.T) {
+	type nested struct {
+		Bytes []byte
+		Ints  *[]int
+	}
+	type master struct {
+		NestedType nested
+		Labels     map[string]interface{}
+		Arr        [1]*nested
+	}
+
+	ints := []int{7}
+	v := &master{
+		NestedType: nested{Bytes: []byte{1}, Ints: &ints},
+		Labels:     map[string]interface{}{"Foo": &nested{}},
+	}
+
+	cfg := spew.ConfigState{Indent: " ", AnnotatePaths: true, DisablePointerAddresses: true}
+	s := cfg.Sdump(v)
+	expected := "(*spew_test.master)({\n" +
+		" NestedType: (spew_test.nested) { // v.NestedType\n" +
+		"  Bytes: ([]uint8) (len=1 cap=1) { // v.NestedType.Bytes\n" +
+		"   00000000  01                                                |.|\n" +
+		"  },\n" +
+		"  Ints: (*[]int)((len=1 cap=1) { // v.NestedType.Ints\n" +
+		"   (int) 7 // (*v.NestedType.Ints)[0]\n" +
+		"  })\n" +
+		" },\n" +
+		" Labels: (map[string]interface {}) (len=1) { // v.Labels\n" +
+		"  (string) (len=3) \"Foo\": (*spew_test.nested)({ // v.Labels[\"Foo\"]\n" +
+		"   Bytes: ([]uint8) <nil>, // v.Labels[\"Foo\"].(*spew_test.nested).Bytes\n" +
+		"   Ints: (*[]int)(<nil>) // v.Labels[\"Foo\"].(*spew_test.nested).Ints\n" +
+		"  })\n" +
+		" },\n" +
+		" Arr: ([1]*spew_test.nested) (len=1 cap=1) { // v.Arr\n" +
+		"  (*spew_test.nested)(<nil>) // v.Arr[0]\n" +
+		" }\n" +
+		"})\n"
+	if s != expected {
+		t.Errorf("Annotated paths mismatch:\n  %v %v", s, expected)
+	}
+
+	cfg.PathRoot = "master"
+	s = cfg.Sdump(map[int][]string{1: {"a"}})
+	expected = "(map[int][]string) (len=1) {\n" +
+		" (int) 1: ([]string) (len=1 cap=1) { // master[1]\n" +
+		"  (string) (len=1) \"a\" // master[1][0]\n" +
+		" }\n" +
+		"}\n"
+	if s != expected {
+		t.Errorf("Annotated paths with root mismatch:\n  %v %v", s, expected)
+	}
+
+	// The Formatter output is never annotated.
+	s = cfg.Sprintf("%v", []int{1})
+	if expected = "[1]"; s != expected {
+		t.Errorf("Annotated formatter mismatch:\n  %v %v", s, expected)
+	}
+}
+
+// TestDumpSnapshot compares the dump of a value of most kinds to its golden
+// file.  Run it with -spewtest.update to rewrite the file after changing the output.
+func TestDumpSnapshot(t *testing.T) {
+	type node struct {
+		Name   string
+		Parent *node
+		Tags   map[string][]byte
+		Values [3]interface{}
+	}
+
+	root := &node{Name: "root"}
+	v := map[string]*node{
+		"root": root,
+		"leaf": {
+			Name:   "leaf",
+			Parent: root,
+			Tags:   map[string][]byte{"b": []byte("spew"), "a": nil},
+			Values: [3]interface{}{int8(-1), 2.5, complex64(1i)},
+		},
+	}
+	spewtest.Snapshot(t, "nodes", v)
 }
 
 func TestDumpHighlightValues(t *testing.T) {
-	cfg := spew.ConfigState{SortKeys: true, HighlightValues: true}
+	cfg := spew.ConfigState{
+		SortKeys:        true,
+		HighlightValues: true,
+		ColorMode:       spew.ColorAlways,
+		Theme: spew.Theme{
+			spew.TString:   gcolor.Green,
+			spew.TInteger:  gcolor.Yellow,
+			spew.TBool:     gcolor.Blue,
+			spew.TTAddress: gcolor.Cyan,
+		},
+	}
 	col := map[string]string{
 		"reset": "\x1b[0m",
 		"str":   "\x1b[32m",
@@ -1087,10 +1239,68 @@ func TestDumpHighlightValues(t *testing.T) {
 		"}\n"
 
 	// replace all pointers with dummyPtr (they will be prefixed by 'm' - from col[], so cannot \b at start) to match expected
-	re := regexp.MustCompile(`0x[0-9a-f]{10}\b`)
+	re := regexp.MustCompile(`0x[0-9a-f]+\b`)
 	s = re.ReplaceAllString(s, dummyPtr)
 
 	if s != expected {
 		t.Errorf("Highlighted other mismatch:\n  %v %v", s, expected)
 	}
 }
+
+// TestDumpConcurrent ensures concurrent dumps with different writers and
+// configurations do not share any (color) state.
+func TestDumpConcurrent(t *testing.T) {
+	type inner struct {
+		Data []byte
+		F    float64
+	}
+	type outer struct {
+		S  string
+		I  int
+		B  bool
+		P  *inner
+		M  map[string]int
+		Is []interface{}
+	}
+	v := outer{
+		S:  "concurrent",
+		I:  42,
+		B:  true,
+		P:  &inner{Data: []byte("Hello World, hello spew!"), F: 3.14},
+		M:  map[string]int{"one": 1, "two": 2},
+		Is: []interface{}{1, "two", 3.0, nil},
+	}
+
+	configs := []*spew.ConfigState{
+		{Indent: " ", SortKeys: true},
+		{Indent: "\t", SortKeys: true, HighlightValues: true},
+		{Indent: "  ", SortKeys: true, HighlightValues: true, HighlightHex: true},
+	}
+	wants := make([]string, len(configs))
+	for i, cs := range configs {
+		wants[i] = cs.Sdump(v)
+	}
+
+	const numIterations = 50
+	var wg sync.WaitGroup
+	errs := make(chan string, len(configs)*numIterations)
+	for i := 0; i < numIterations; i++ {
+		for j, cs := range configs {
+			wg.Add(1)
+			go func(cs *spew.ConfigState, want string) {
+				defer wg.Done()
+				buf := new(bytes.Buffer)
+				cs.Fdump(buf, v)
+				if s := buf.String(); s != want {
+					errs <- fmt.Sprintf("got: %s want: %s", s, want)
+				}
+			}(cs, wants[j])
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent dump mismatch\n %s", err)
+	}
+}
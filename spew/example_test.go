@@ -224,3 +224,22 @@ func ExampleConfigState_Printf() {
 	// f: 1
 	// f: flagTwo
 }
+
+// This example demonstrates how to use ConfigState.Diff to compare two values.
+func ExampleConfigState_Diff() {
+	// See the top-level Dump example for details on the types used in this
+	// example.
+
+	scs := spew.ConfigState{SortKeys: true}
+
+	want := Foo{ExportedField: map[interface{}]interface{}{"one": true}}
+	got := Foo{ExportedField: map[interface{}]interface{}{"one": false, "two": 2}}
+	got.unexportedField.data = 5
+
+	fmt.Print(scs.Diff(want, got))
+
+	// Output:
+	// .unexportedField.data: (uintptr) <nil> -> (uintptr) 0x5
+	// .ExportedField["one"]: (bool) true -> (bool) false
+	// .ExportedField["two"]: <missing> -> (int) 2
+}
@@ -0,0 +1,160 @@
+package spew
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldAction specifies how a struct field is displayed.
+type FieldAction int
+
+const (
+	// FieldShow displays the field as usual.
+	FieldShow FieldAction = iota
+
+	// FieldSkip omits the field entirely.
+	FieldSkip
+
+	// FieldRedact displays the field name, but <redacted> instead of its
+	// value.
+	FieldRedact
+)
+
+// FieldRule applies an Action to the struct fields it matches.  It allows to
+// skip or redact fields of types which can not be tagged, e.g. because they
+// are defined by third-party packages.
+//
+// A rule matches a field if all of its non-empty criteria match.  Name and
+// Path are patterns in which '*' matches any sequence of characters.
+type FieldRule struct {
+	// Name is matched case-insensitively against the name of the field,
+	// e.g. "*password*".
+	Name string
+
+	// Type is matched against the type of the field and, for fields of an
+	// interface type, against the type of the value stored in it.
+	Type reflect.Type
+
+	// Path is matched against the path of the field, which consists of
+	// field names, indices and map keys, e.g. ".DB.Password",
+	// ".Users[0].Token" or "*.Token".
+	Path string
+
+	// Action specifies how the matched fields are displayed.
+	Action FieldAction
+}
+
+// matches returns whether r matches the struct field sf holding v, which is
+// reached by path.
+func (r *FieldRule) matches(sf reflect.StructField, v reflect.Value, path string) bool {
+	if r.Name == "" && r.Type == nil && r.Path == "" {
+		return false
+	}
+
+	if r.Name != "" && !matchPattern(strings.ToLower(r.Name), strings.ToLower(sf.Name)) {
+		return false
+	}
+
+	if r.Type != nil && sf.Type != r.Type {
+		if v.Kind() != reflect.Interface || v.IsNil() || v.Elem().Type() != r.Type {
+			return false
+		}
+	}
+
+	if r.Path != "" && !matchPattern(r.Path, path) {
+		return false
+	}
+
+	return true
+}
+
+// shownField is a struct field which is displayed.
+type shownField struct {
+	// index is the index of the field in its struct.
+	index int
+
+	// path is the path of the field.  It is only set if paths are needed
+	// to apply the FieldRules, see ConfigState.tracksPaths.
+	path string
+
+	// redact specifies whether <redacted> is displayed instead of the
+	// value of the field.
+	redact bool
+}
+
+// fieldAction returns how the struct field sf holding v, which is reached by
+// path, is displayed.  The spew struct tag takes precedence over the
+// FieldRules of c, of which the first matching one is applied.
+func (c *ConfigState) fieldAction(sf reflect.StructField, v reflect.Value, path string) FieldAction {
+	tag := sf.Tag.Get("spew")
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		tag = tag[:i]
+	}
+
+	switch tag {
+	case "-":
+		return FieldSkip
+	case "redact":
+		return FieldRedact
+	}
+
+	for i := range c.FieldRules {
+		if c.FieldRules[i].matches(sf, v, path) {
+			return c.FieldRules[i].Action
+		}
+	}
+
+	return FieldShow
+}
+
+// shownFields returns the fields of the struct v, which is reached by path,
+// which are displayed.
+func (c *ConfigState) shownFields(v reflect.Value, path string) []shownField {
+	vt := v.Type()
+	numFields := v.NumField()
+	fields := make([]shownField, 0, numFields)
+
+	tracksPaths := c.tracksPaths()
+	for i := 0; i < numFields; i++ {
+		sf := vt.Field(i)
+
+		var fp string
+		if tracksPaths {
+			fp = fieldPath(path, sf.Name)
+		}
+
+		switch c.fieldAction(sf, v.Field(i), fp) {
+		case FieldSkip:
+			continue
+		case FieldRedact:
+			fields = append(fields, shownField{index: i, path: fp, redact: true})
+		default:
+			fields = append(fields, shownField{index: i, path: fp})
+		}
+	}
+
+	return fields
+}
+
+// tracksPaths returns whether the paths of values need to be tracked while
+// dumping, which is only the case if shared pointers are detected or a
+// FieldRule matches paths.
+func (c *ConfigState) tracksPaths() bool {
+	if c.sharesPaths() {
+		return true
+	}
+
+	for i := range c.FieldRules {
+		if c.FieldRules[i].Path != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sharesPaths returns whether pointers to already displayed targets are
+// displayed as references to their paths, see DetectSharedPointers.
+func (c *ConfigState) sharesPaths() bool {
+	return c.DetectSharedPointers && !c.PointerIDs
+}
@@ -0,0 +1,80 @@
+package spew_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+type secret string
+
+type filterCreds struct {
+	User     string
+	Password string `spew:"redact"`
+	internal int    `spew:"-"`
+}
+
+type filterConfig struct {
+	Name   string
+	Creds  filterCreds
+	Token  string
+	Key    secret
+	Any    interface{}
+	Users  []filterCreds
+	APIKey string
+}
+
+// TestFieldFiltering ensures struct tags and field rules skip and redact
+// fields in Dump, Formatter and JSON output.
+func TestFieldFiltering(t *testing.T) {
+	v := filterConfig{
+		Name:   "n",
+		Creds:  filterCreds{User: "u", Password: "p", internal: 1},
+		Token:  "t",
+		Key:    "k",
+		Any:    secret("a"),
+		Users:  []filterCreds{{User: "x", Password: "y"}},
+		APIKey: "ak",
+	}
+
+	tagsOnly := &spew.ConfigState{DisableMethods: true}
+	rules := &spew.ConfigState{
+		DisableMethods: true,
+		FieldRules: []spew.FieldRule{
+			{Name: "*token*", Action: spew.FieldSkip},
+			{Type: reflect.TypeOf(secret("")), Action: spew.FieldRedact},
+			{Path: "*[*].User", Action: spew.FieldRedact},
+			{Path: "*Key", Name: "api*", Action: spew.FieldSkip},
+		},
+	}
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{tagsOnly.Sdump(v.Creds), "(spew_test.filterCreds) {\n" +
+			"User: (string) (len=1) \"u\",\n" +
+			"Password: <redacted>\n" +
+			"}\n"},
+		{tagsOnly.Sprintf("%+v", v.Creds), "{User:u Password:<redacted>}"},
+		{tagsOnly.Sprintf("%v", v.Creds), "{u <redacted>}"},
+		{rules.Sprintf("%+v", v), "{Name:n Creds:{User:u Password:<redacted>} " +
+			"Key:<redacted> Any:<redacted> Users:[{User:<redacted> Password:<redacted>}]}"},
+		{rules.Sjson(v.Creds), `{"type":"spew_test.filterCreds","fields":[` +
+			`{"name":"User","value":{"type":"string","len":1,"value":"u"}},` +
+			`{"name":"Password","value":{"redacted":true}}]}` + "\n"},
+		{rules.Sdump(v.Users), "([]spew_test.filterCreds) (len=1 cap=1) {\n" +
+			"(spew_test.filterCreds) {\n" +
+			"User: <redacted>,\n" +
+			"Password: <redacted>\n" +
+			"}\n" +
+			"}\n"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("FieldFiltering #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+}
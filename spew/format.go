@@ -19,6 +19,7 @@ package spew
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"reflect"
 	"strconv"
 	"strings"
@@ -34,29 +35,23 @@ const supportedFlags = "0-+# "
 type formatState struct {
 	value          interface{}
 	fs             fmt.State
-	depth          int
-	pointers       map[uintptr]int
 	ignoreNextType bool
 	cs             *ConfigState
-}
 
-// buildDefaultFormat recreates the original format string without precision
-// and width information to pass in to fmt.Sprintf in the case of an
-// unrecognized type.  Unless new types are added to the language, this
-// function won't ever be called.
-func (f *formatState) buildDefaultFormat() (format string) {
-	buf := bytes.NewBuffer(percentBytes)
+	// walker walks the value formatted.  Each call of format creates a new
+	// one, so pointer IDs and shared pointers are detected anew every time
+	// the Formatter is formatted.
+	walker *walkState
 
-	for _, flag := range supportedFlags {
-		if f.fs.Flag(int(flag)) {
-			buf.WriteRune(flag)
-		}
-	}
+	// cw is the colorWriter fs writes through, if any.
+	cw *colorWriter
 
-	buf.WriteRune('v')
+	// dest is the final destination of the formatted output, if known.  It
+	// is used to decide whether colors are emitted.
+	dest io.Writer
 
-	format = buf.String()
-	return format
+	// lw is the limitWriter enforcing MaxOutputBytes, if any.
+	lw *limitWriter
 }
 
 // constructOrigFormat recreates the original format string including precision
@@ -86,315 +81,389 @@ func (f *formatState) constructOrigFormat(verb rune) (format string) {
 	return format
 }
 
-// unpackValue returns values inside of non-nil interfaces when possible and
-// ensures that types for values which have been unpacked from an interface
-// are displayed when the show types flag is also set.
-// This is useful for data types like structs, arrays, slices, and maps which
-// can contain varying types packed inside an interface.
-func (f *formatState) unpackValue(v reflect.Value) reflect.Value {
-	if v.Kind() == reflect.Interface {
-		f.ignoreNextType = false
-		if !v.IsNil() {
-			v = v.Elem()
-		}
-	}
-	return v
-}
-
-// formatPtr handles formatting of pointers by indirecting them as necessary.
-func (f *formatState) formatPtr(v reflect.Value) {
+// formatPtr handles formatting of pointers, which are described by the node
+// of the value at the end of the chain.
+func (f *formatState) formatPtr(n *Node) {
 	// Display nil if top level pointer is nil.
 	showTypes := f.fs.Flag('#')
-	if v.IsNil() && (!showTypes || f.ignoreNextType) {
-		f.fs.Write(nilAngleBytes)
+	if n.Nil && n.Indirects == 0 && (!showTypes || f.ignoreNextType) {
+		f.writeColored(TNil, nilAngleBytes)
 		return
 	}
 
-	// Remove pointers at or below the current depth from map used to detect
-	// circular refs.
-	for k, depth := range f.pointers {
-		if depth >= f.depth {
-			delete(f.pointers, k)
-		}
-	}
-
-	// Keep list of all dereferenced pointers to possibly show later.
-	pointerChain := make([]uintptr, 0)
-
-	// Figure out how many levels of indirection there are by derferencing
-	// pointers and unpacking interfaces down the chain while detecting circular
-	// references.
-	nilFound := false
-	cycleFound := false
-	indirects := 0
-	ve := v
-	for ve.Kind() == reflect.Ptr {
-		if ve.IsNil() {
-			nilFound = true
-			break
-		}
-		indirects++
-		addr := ve.Pointer()
-		pointerChain = append(pointerChain, addr)
-		if pd, ok := f.pointers[addr]; ok && pd < f.depth {
-			cycleFound = true
-			indirects--
-			break
-		}
-		f.pointers[addr] = f.depth
-
-		ve = ve.Elem()
-		if ve.Kind() == reflect.Interface {
-			if ve.IsNil() {
-				nilFound = true
-				break
-			}
-			ve = ve.Elem()
-		}
-	}
-
 	// Display type or indirection level depending on flags.
 	if showTypes && !f.ignoreNextType {
 		f.fs.Write(openParenBytes)
-		f.fs.Write(bytes.Repeat(asteriskBytes, indirects))
-		f.fs.Write([]byte(ve.Type().String()))
+		f.cw.colorPtr(n.typ.String())
+		f.fs.Write(bytes.Repeat(asteriskBytes, n.Indirects))
+		f.fs.Write([]byte(n.typ.String()))
+		f.cw.stopColor()
 		f.fs.Write(closeParenBytes)
 	} else {
-		if nilFound || cycleFound {
-			indirects += strings.Count(ve.Type().String(), "*")
+		indirects := n.Indirects
+		if n.Nil || n.Circular || n.Ref != "" {
+			indirects += strings.Count(n.typ.String(), "*")
 		}
 		f.fs.Write(openAngleBytes)
+		f.cw.rawColor(TTPtr)
 		f.fs.Write([]byte(strings.Repeat("*", indirects)))
+		f.cw.stopColor()
 		f.fs.Write(closeAngleBytes)
 	}
 
 	// Display pointer information depending on flags.
-	if f.fs.Flag('+') && (len(pointerChain) > 0) {
+	if (f.cs.PointerIDs || f.fs.Flag('+')) && (len(n.Addrs) > 0) {
 		f.fs.Write(openParenBytes)
-		for i, addr := range pointerChain {
+		for i, addr := range n.Addrs {
 			if i > 0 {
 				f.fs.Write(pointerChainBytes)
 			}
-			printHexPtr(f.fs, addr)
+			f.writeColored(TTAddress, []byte(addr))
 		}
 		f.fs.Write(closeParenBytes)
 	}
 
 	// Display dereferenced value.
 	switch {
-	case nilFound:
-		f.fs.Write(nilAngleBytes)
+	case n.Nil:
+		f.writeColored(TNil, nilAngleBytes)
+
+	case strings.HasPrefix(n.Ref, string(ampersandBytes)):
+		f.cw.rawColor(TCircular)
+		f.fs.Write(pointerRefBytes)
+		f.fs.Write([]byte(n.Ref))
+		f.cw.stopColor()
+
+	case n.Ref != "":
+		f.cw.rawColor(TCircular)
+		printSharedPath(f.fs, n.Ref)
+		f.cw.stopColor()
 
-	case cycleFound:
-		f.fs.Write(circularShortBytes)
+	case n.Circular:
+		f.writeColored(TCircular, circularShortBytes)
 
 	default:
 		f.ignoreNextType = true
-		f.format(ve)
+		f.formatValue(n)
 	}
 }
 
 // format is the main workhorse for providing the Formatter interface.  It
-// uses the passed reflect value to figure out what kind of object we are
-// dealing with and formats it appropriately.  It is a recursive function,
-// however circular data structures are detected and handled properly.
+// walks the passed reflect value and renders the resulting node.  Circular
+// data structures are detected and handled properly by the walk.
 func (f *formatState) format(v reflect.Value) {
+	// Stop walking the value once the output is truncated.
+	if limitFull(f.lw) {
+		return
+	}
+
+	f.walker = newWalkState(nil, f.cs, true)
+	f.walker.more = func() bool { return !limitFull(f.lw) }
+	f.render(f.walker.node(v))
+}
+
+// render writes the node n and walks its children while writing them.
+func (f *formatState) render(n *Node) {
+	// Stop once the output is truncated.
+	if limitFull(f.lw) {
+		return
+	}
+
 	// Handle invalid reflect values immediately.
-	kind := v.Kind()
-	if kind == reflect.Invalid {
+	if n.Invalid {
 		f.fs.Write(invalidAngleBytes)
 		return
 	}
 
 	// Handle pointers specially.
-	if kind == reflect.Ptr {
-		f.formatPtr(v)
+	if n.isPtr() {
+		f.formatPtr(n)
 		return
 	}
 
+	f.formatValue(n)
+}
+
+// formatValue writes the value described by n, which is the value pointed to
+// for pointers.
+func (f *formatState) formatValue(n *Node) {
 	// Print type information unless already handled elsewhere.
 	if !f.ignoreNextType && f.fs.Flag('#') {
 		f.fs.Write(openParenBytes)
-		f.fs.Write([]byte(v.Type().String()))
+		f.cw.typeColor(n.typ)
+		f.fs.Write([]byte(n.Type))
+		f.cw.stopColor()
 		f.fs.Write(closeParenBytes)
 	}
 	f.ignoreNextType = false
 
-	// Call Stringer/error interfaces if they exist and the handle methods
-	// flag is enabled.
-	if !f.cs.DisableMethods {
-		if (kind != reflect.Invalid) && (kind != reflect.Interface) {
-			if handled := handleMethods(f.cs, f.fs, v); handled {
-				return
-			}
-		}
+	// Let custom formatters and SpewFormatter/SpewDumper implementations
+	// render the value.
+	if n.custom != nil {
+		f.formatCustom(n)
+		return
 	}
 
-	switch kind {
-	case reflect.Invalid:
-		// Do nothing.  We should never get here since invalid has already
-		// been handled above.
-
-	case reflect.Bool:
-		printBool(f.fs, v.Bool())
-
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		printInt(f.fs, v.Int(), 10)
-
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		printUint(f.fs, v.Uint(), 10)
-
-	case reflect.Float32:
-		printFloat(f.fs, v.Float(), 32)
-
-	case reflect.Float64:
-		printFloat(f.fs, v.Float(), 64)
+	// Display time values in a human readable form rather than their
+	// internal representation.
+	if n.time {
+		f.writeColored(TDate, []byte(n.Value))
+		return
+	}
 
-	case reflect.Complex64:
-		printComplex(f.fs, v.Complex(), 32)
+	// Display the result of the Stringer/error interfaces, if called.
+	if n.call != nil && n.call.write(f.cs, f.fs) {
+		return
+	}
 
-	case reflect.Complex128:
-		printComplex(f.fs, v.Complex(), 64)
+	f.cw.color(n)
 
-	case reflect.Slice:
-		if v.IsNil() {
+	switch n.Kind {
+	case reflect.Slice, reflect.Array:
+		if n.Nil {
 			f.fs.Write(nilAngleBytes)
 			break
 		}
-		fallthrough
 
-	case reflect.Array:
 		f.fs.Write(openBracketBytes)
-		f.depth++
-		if (f.cs.MaxDepth != 0) && (f.depth > f.cs.MaxDepth) {
-			f.fs.Write(maxShortBytes)
+		f.walker.children(n, f.formatChild)
+		if n.MaxDepth {
+			f.writeMaxShort()
 		} else {
-			numEntries := v.Len()
-			for i := 0; i < numEntries; i++ {
-				if i > 0 {
-					f.fs.Write(spaceBytes)
-				}
-				f.ignoreNextType = true
-				f.format(f.unpackValue(v.Index(i)))
-			}
+			f.writeMore(n.Len-n.More, n.More)
 		}
-		f.depth--
 		f.fs.Write(closeBracketBytes)
 
 	case reflect.String:
-		f.fs.Write([]byte(v.String()))
-
-	case reflect.Interface:
-		// The only time we should get here is for nil interfaces due to
-		// unpackValue calls.
-		if v.IsNil() {
-			f.fs.Write(nilAngleBytes)
+		f.fs.Write([]byte(n.Value))
+		if n.More > 0 {
+			f.cw.stopColor()
+			f.fs.Write(spaceBytes)
+			f.writeColored(TTruncated, moreMarker(n.More, moreBytesSuffix))
 		}
 
-	case reflect.Ptr:
-		// Do nothing.  We should never get here since pointers have already
-		// been handled above.
-
 	case reflect.Map:
 		// nil maps should be indicated as different than empty maps
-		if v.IsNil() {
+		if n.Nil {
 			f.fs.Write(nilAngleBytes)
 			break
 		}
 
-		f.fs.Write(openMapBytes)
-		f.depth++
-		if (f.cs.MaxDepth != 0) && (f.depth > f.cs.MaxDepth) {
-			f.fs.Write(maxShortBytes)
+		f.writeColored(TMap, openMapBytes)
+		f.walker.children(n, f.formatChild)
+		if n.MaxDepth {
+			f.writeMaxShort()
 		} else {
-			keys := v.MapKeys()
-			if f.cs.SortKeys {
-				sortValues(keys, f.cs)
+			f.writeMore(n.Len-n.More, n.More)
+		}
+		f.writeColored(TMap, closeMapBytes)
+
+	case reflect.Struct:
+		f.writeColored(TStruct, openBraceBytes)
+		f.walker.children(n, f.formatChild)
+		if n.MaxDepth {
+			f.writeMaxShort()
+		}
+		f.writeColored(TStruct, closeBraceBytes)
+
+	case reflect.Interface:
+		// The only time we should get here is for nil interfaces.
+		if n.Nil {
+			f.fs.Write(nilAngleBytes)
+		}
+
+	default:
+		f.fs.Write([]byte(n.Value))
+	}
+
+	f.cw.stopColor()
+}
+
+// formatChild writes an element, map entry or struct field of the value
+// currently formatted.  It returns whether the output is written any
+// further.
+func (f *formatState) formatChild(p part) bool {
+	switch p.kind {
+	case partElem:
+		if p.index > 0 {
+			f.fs.Write(spaceBytes)
+		}
+		f.ignoreNextType = !p.node.iface
+		f.render(p.node)
+
+	case partKey:
+		if p.index > 0 {
+			f.fs.Write(spaceBytes)
+		}
+		f.ignoreNextType = !p.node.iface
+		f.render(p.node)
+		f.fs.Write(colonBytes)
+
+	case partValue:
+		f.ignoreNextType = !p.node.iface
+		f.render(p.node)
+
+	case partField:
+		if p.index > 0 {
+			f.fs.Write(spaceBytes)
+		}
+		f.writeFieldName(p.node.Name)
+		if p.node.Redacted {
+			f.writeColored(TRedacted, redactedBytes)
+			break
+		}
+		if p.node.iface {
+			f.ignoreNextType = false
+		}
+		f.render(p.node)
+	}
+
+	return true
+}
+
+// writeFieldName writes the name of a struct field if the verb asks for
+// field names.
+func (f *formatState) writeFieldName(name string) {
+	if f.fs.Flag('+') || f.fs.Flag('#') {
+		f.writeColored(TFieldName, []byte(name))
+		f.fs.Write(colonBytes)
+	}
+}
+
+// formatCustom writes the value n as rendered by its custom formatter or
+// SpewFormatter/SpewDumper implementation, see Printer.  Blocks of elements
+// are delimited by brackets and blocks of fields by braces.
+func (f *formatState) formatCustom(n *Node) {
+	count := 0
+	closing := closeBraceBytes
+	f.walker.children(n, func(p part) bool {
+		switch p.kind {
+		case partText:
+			if p.colored {
+				f.writeColored(p.typ, []byte(p.text))
+			} else {
+				f.fs.Write([]byte(p.text))
 			}
-			for i, key := range keys {
-				if i > 0 {
-					f.fs.Write(spaceBytes)
+
+		case partInline:
+			if p.node.iface {
+				f.ignoreNextType = false
+			}
+			f.render(p.node)
+
+		case partOpen:
+			opening := openBraceBytes
+			if p.elems {
+				opening, closing = openBracketBytes, closeBracketBytes
+			}
+			f.writeColored(TStruct, opening)
+			if n.MaxDepth {
+				f.writeMaxShort()
+			}
+
+		case partField, partElem:
+			if count > 0 {
+				f.fs.Write(spaceBytes)
+			}
+			if p.kind == partField {
+				f.writeFieldName(p.node.Name)
+				if p.node.iface {
+					f.ignoreNextType = false
 				}
-				f.ignoreNextType = true
-				f.format(f.unpackValue(key))
-				f.fs.Write(colonBytes)
-				f.ignoreNextType = true
-				f.format(f.unpackValue(v.MapIndex(key)))
+			} else {
+				f.ignoreNextType = !p.node.iface
 			}
-		}
-		f.depth--
-		f.fs.Write(closeMapBytes)
+			f.render(p.node)
+			count++
 
-	case reflect.Struct:
-		numFields := v.NumField()
-		f.fs.Write(openBraceBytes)
-		f.depth++
-		if (f.cs.MaxDepth != 0) && (f.depth > f.cs.MaxDepth) {
-			f.fs.Write(maxShortBytes)
-		} else {
-			vt := v.Type()
-			for i := 0; i < numFields; i++ {
-				if i > 0 {
+		case partClose:
+			if n.More > 0 {
+				if count > 0 {
 					f.fs.Write(spaceBytes)
 				}
-				vtf := vt.Field(i)
-				if f.fs.Flag('+') || f.fs.Flag('#') {
-					f.fs.Write([]byte(vtf.Name))
-					f.fs.Write(colonBytes)
-				}
-				f.format(f.unpackValue(v.Field(i)))
+				f.writeColored(TTruncated, moreMarker(n.More, moreSuffix))
 			}
+			f.writeColored(TStruct, closing)
 		}
-		f.depth--
-		f.fs.Write(closeBraceBytes)
 
-	case reflect.Uintptr:
-		printHexPtr(f.fs, uintptr(v.Uint()))
+		return !limitFull(f.lw)
+	})
+}
 
-	case reflect.UnsafePointer, reflect.Chan, reflect.Func:
-		printHexPtr(f.fs, v.Pointer())
+// writeColored writes b in the color of type t.
+func (f *formatState) writeColored(t Type, b []byte) {
+	f.cw.rawColor(t)
+	f.fs.Write(b)
+	f.cw.stopColor()
+}
 
-	// There were not any other types at the time this code was written, but
-	// fall back to letting the default fmt package handle it if any get added.
-	default:
-		format := f.buildDefaultFormat()
-		if v.CanInterface() {
-			fmt.Fprintf(f.fs, format, v.Interface())
-		} else {
-			fmt.Fprintf(f.fs, format, v.String())
-		}
+// writeMore writes the marker for n omitted elements following the shown
+// ones, unless n is 0.
+func (f *formatState) writeMore(shown, n int) {
+	if n == 0 {
+		return
 	}
+
+	if shown > 0 {
+		f.fs.Write(spaceBytes)
+	}
+	f.writeColored(TTruncated, moreMarker(n, moreSuffix))
+}
+
+// writeMaxShort writes the marker for a reached maximum depth.
+func (f *formatState) writeMaxShort() {
+	f.cw.rawColor(TMaxDepth)
+	f.fs.Write(maxShortBytes)
+	f.cw.stopColor()
 }
 
 // Format satisfies the fmt.Formatter interface. See NewFormatter for usage
 // details.
 func (f *formatState) Format(fs fmt.State, verb rune) {
-	f.fs = fs
+	var w io.Writer = fs
+	lvl := f.cs.colorLevel(f.dest)
+	if f.cs.MaxOutputBytes > 0 {
+		f.lw = newLimitWriter(fs, f.cs.MaxOutputBytes, lvl != levelNone, outputTruncatedBytes)
+		w = f.lw
+	}
+	f.cw = newColorWriter(w, f.cs, lvl)
+	f.fs = &colorFmtState{State: fs, cw: f.cw}
 
 	// Use standard formatting for verbs that are not v.
 	if verb != 'v' {
 		format := f.constructOrigFormat(verb)
-		fmt.Fprintf(fs, format, f.value)
+		fmt.Fprintf(f.fs, format, f.value)
 		return
 	}
 
 	if f.value == nil {
 		if fs.Flag('#') {
-			fs.Write(interfaceBytes)
+			f.fs.Write(interfaceBytes)
 		}
-		fs.Write(nilAngleBytes)
+		f.cw.rawColor(TNil)
+		f.fs.Write(nilAngleBytes)
+		f.cw.stopColor()
 		return
 	}
 
 	f.format(reflect.ValueOf(f.value))
 }
 
+// colorFmtState wraps a fmt.State so that everything written to it passes
+// through the colorWriter of the current formatting operation.
+type colorFmtState struct {
+	fmt.State
+	cw *colorWriter
+}
+
+func (s *colorFmtState) Write(p []byte) (n int, err error) {
+	return s.cw.Write(p)
+}
+
 // newFormatter is a helper function to consolidate the logic from the various
 // public methods which take varying config states.
-func newFormatter(cs *ConfigState, v interface{}) fmt.Formatter {
-	fs := &formatState{value: v, cs: cs}
-	fs.pointers = make(map[uintptr]int)
-	return fs
+func newFormatter(cs *ConfigState, v interface{}) *formatState {
+	return &formatState{value: v, cs: cs}
 }
 
 /*
@@ -70,6 +70,7 @@ import (
 	"bytes"
 	"fmt"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/l0nax/go-spew/spew"
@@ -1461,6 +1462,54 @@ func addPassthroughFormatterTests() {
 	addFormatterTest("%q", "test", "\"test\"")
 }
 
+func addTimeFormatterTests() {
+	// Time values are formatted in RFC3339 format.
+	v := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	pv := &v
+	vAddr := fmt.Sprintf("%p", pv)
+	vt := "time.Time"
+	vs := "2021-03-04T05:06:07Z"
+	addFormatterTest("%v", v, vs)
+	addFormatterTest("%v", pv, "<*>"+vs)
+	addFormatterTest("%+v", pv, "<*>("+vAddr+")"+vs)
+	addFormatterTest("%#v", v, "("+vt+")"+vs)
+	addFormatterTest("%#v", pv, "(*"+vt+")"+vs)
+
+	// Durations are formatted like time.Duration.String.
+	v2 := 1500 * time.Millisecond
+	v2t := "time.Duration"
+	v2s := "1.5s"
+	addFormatterTest("%v", v2, v2s)
+	addFormatterTest("%#v", v2, "("+v2t+")"+v2s)
+
+	// Locations are formatted by their name.
+	v3 := time.UTC
+	v3t := "time.Location"
+	v3s := "UTC"
+	addFormatterTest("%v", v3, "<*>"+v3s)
+	addFormatterTest("%#v", v3, "(*"+v3t+")"+v3s)
+
+	// Time values in unexported struct fields.
+	type timeStruct struct {
+		t time.Time
+		d time.Duration
+	}
+	v4 := timeStruct{v, v2}
+	v4t := "spew_test.timeStruct"
+	v4s := "{" + vs + " " + v2s + "}"
+	v4s2 := "{t:" + vs + " d:" + v2s + "}"
+	v4s3 := "{t:(" + vt + ")" + vs + " d:(" + v2t + ")" + v2s + "}"
+	if spew.UnsafeDisabled {
+		v4s = "{{0 63750431167 <nil>} " + v2s + "}"
+		v4s2 = "{t:{wall:0 ext:63750431167 loc:<nil>} d:" + v2s + "}"
+		v4s3 = "{t:(" + vt + "){wall:(uint64)0 ext:(int64)63750431167 " +
+			"loc:(*time.Location)<nil>} d:(" + v2t + ")" + v2s + "}"
+	}
+	addFormatterTest("%v", v4, v4s)
+	addFormatterTest("%+v", v4, v4s2)
+	addFormatterTest("%#v", v4, "("+v4t+")"+v4s3)
+}
+
 // TestFormatter executes all of the tests described by formatterTests.
 func TestFormatter(t *testing.T) {
 	// Setup tests.
@@ -1482,6 +1531,7 @@ func TestFormatter(t *testing.T) {
 	addCircularFormatterTests()
 	addPanicFormatterTests()
 	addErrorFormatterTests()
+	addTimeFormatterTests()
 	addPassthroughFormatterTests()
 
 	t.Logf("Running %d tests", len(formatterTests))
@@ -1556,3 +1606,31 @@ func TestPrintSortedKeys(t *testing.T) {
 		t.Errorf("Sorted keys mismatch 6:\n  %v %v", s, expected)
 	}
 }
+
+// TestFormatterReuse ensures formatting the same Formatter more than once
+// produces the same output every time.
+func TestFormatterReuse(t *testing.T) {
+	type shared struct {
+		A, B *int
+		C    []int
+	}
+	v := 5
+	in := shared{&v, &v, []int{1, 2, 3}}
+
+	tests := []struct {
+		cs   *spew.ConfigState
+		want string
+	}{
+		{&spew.ConfigState{PointerIDs: true}, "{<*>(&1)5 <*>-> &1 [1 2 3]}"},
+		{&spew.ConfigState{DetectSharedPointers: true, DisablePointerAddresses: true}, "{<*>5 <*><same as .A> [1 2 3]}"},
+	}
+
+	for i, test := range tests {
+		f := test.cs.NewFormatter(in)
+		for j := 0; j < 2; j++ {
+			if s := fmt.Sprintf("%v", f); s != test.want {
+				t.Errorf("FormatterReuse #%d print %d\n got: %s want: %s", i, j, s, test.want)
+			}
+		}
+	}
+}
@@ -1,35 +1,156 @@
 package spew
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Implements the encoding/hex package but with colors.
 // NOTE: The code has been COPIED from https://golang.org/src/encoding/hex/hex.go
 //		 => Copyright (c) 2009 The Go Authors. All rights reserved.
 
+// HexDump returns a string that contains a hex dump of the given data in the
+// layout of the hexdump -C command.  The dump is colorized with DefaultTheme
+// if colorize is true.  See HexDumpWith for other layouts.
 func HexDump(data []byte, colorize bool) string {
+	return HexDumpWith(data, HexDumpOptions{Theme: defaultTheme(colorize)})
+}
+
+// HexDumpOptions controls the layout of hex dumps.  The zero value produces
+// the layout of the hexdump -C command.
+type HexDumpOptions struct {
+	// BytesPerLine is the number of bytes dumped per line.  The default, 0,
+	// means 16.
+	BytesPerLine int
+
+	// GroupSize is the number of bytes after which an extra space separates
+	// the hex values of a line.  The default, 0, means 8.  A negative value
+	// disables grouping.
+	GroupSize int
+
+	// Uppercase specifies whether hex digits are displayed in uppercase.
+	Uppercase bool
+
+	// OffsetWidth is the minimum number of hex digits of the offsets.  The
+	// default, 0, means 8.  Larger offsets are displayed with as many
+	// digits as needed, so they never wrap around.
+	OffsetWidth int
+
+	// BaseOffset is added to the displayed offsets, e.g. when dumping a part
+	// of a larger buffer or file.
+	BaseOffset uint64
+
+	// HideASCII specifies whether to hide the column which displays the
+	// bytes as characters.
+	HideASCII bool
+
+	// UTF8 specifies whether valid UTF-8 encoded characters are displayed as
+	// such in the character column, instead of one dot per byte.
+	UTF8 bool
+
+	// Squeeze specifies whether runs of identical lines are replaced by a
+	// single line containing "*", like the hexdump -C command does.  The
+	// dump continues with the next differing line and its offset.  If the
+	// data ends with such a run, a last line holds the offset of its end.
+	Squeeze bool
+
+	// Theme specifies the colors of the dump.  The default, nil, disables
+	// colorization.  It is ignored for the hex dumps of Dump, which are
+	// colorized according to HighlightHex.
+	Theme Theme
+}
+
+// normalized returns o with the defaults of unset fields filled in.
+func (o HexDumpOptions) normalized() HexDumpOptions {
+	if o.BytesPerLine <= 0 {
+		o.BytesPerLine = 16
+	}
+	if o.GroupSize == 0 {
+		o.GroupSize = 8
+	}
+	if o.OffsetWidth <= 0 {
+		o.OffsetWidth = 8
+	}
+
+	return o
+}
+
+// HexDumpWith returns a string that contains a hex dump of the given data
+// with the layout specified by opts.
+func HexDumpWith(data []byte, opts HexDumpOptions) string {
 	if len(data) == 0 {
 		return ""
 	}
 
+	opts = opts.normalized()
+
 	var buf strings.Builder
-	// Dumper will write 79 bytes per complete 16 byte chunk, and at least
-	// 64 bytes for whatever remains. Round the allocation up, since only a
-	// maximum of 15 bytes will be wasted.
+	// A line consists of the offset, three characters per byte for its hex
+	// value and the separators, one for its character and a few delimiters.
 	// NOTE: The buffer will grow if over the calculated size, if colorization
 	//		 of the hex dump is enabled!
-	buf.Grow((1 + ((len(data) - 1) / 16)) * 79)
+	lines := 1 + (len(data)-1)/opts.BytesPerLine
+	buf.Grow(lines * (opts.OffsetWidth + 4*opts.BytesPerLine + opts.BytesPerLine/8 + 6))
 
-	dumper := Dumper(&buf, colorize)
+	dumper := newDumper(&buf, opts)
 	dumper.Write(data)
 	dumper.Close()
 	return buf.String()
 }
 
-const hextable = "0123456789abcdef"
+// byteWindows returns where the first and last bytes shown of n bytes, which
+// are limited to max, end and start.  The bytes in between are omitted.
+func byteWindows(n, max int) (head, tail int) {
+	head = max / 2
+	return head, n - (max - head)
+}
+
+// hexDumpWindows is like HexDumpWith, but only dumps the first and last bytes
+// of data if it is longer than max bytes.  A max of 0 means there is no limit.
+func hexDumpWindows(data []byte, max int, opts HexDumpOptions) string {
+	if max <= 0 || len(data) <= max {
+		return HexDumpWith(data, opts)
+	}
+
+	// The tail dump starts at the offset of its first byte, so its offsets
+	// are the ones of a complete dump.
+	head, tail := byteWindows(len(data), max)
+
+	marker := string(moreMarker(tail-head, bytesOmittedSuffix))
+	if col := opts.Theme[TTruncated]; col != nil {
+		marker = col.Sprint(marker)
+	}
+
+	tailOpts := opts
+	tailOpts.BaseOffset += uint64(tail)
+
+	return HexDumpWith(data[:head], opts) + marker + "\n" +
+		HexDumpWith(data[tail:], tailOpts)
+}
+
+// defaultTheme returns DefaultTheme if colorize is true, and nil otherwise.
+func defaultTheme(colorize bool) Theme {
+	if !colorize {
+		return nil
+	}
+
+	return DefaultTheme
+}
+
+var squeezeBytes = []byte("*\n")
+
+const (
+	hextable      = "0123456789abcdef"
+	hextableUpper = "0123456789ABCDEF"
+)
 
 // HexEncode encodes src into EncodedLen(len(src))
 // bytes of dst. As a convenience, it returns the number
@@ -37,7 +158,7 @@ const hextable = "0123456789abcdef"
 // Encode implements hexadecimal encoding.
 func HexEncode(dst, src []byte, colorize bool) ([]byte, int) {
 	if colorize {
-		return hexColorEncode(src)
+		return hexColorEncode(src, DefaultTheme)
 	}
 
 	j := 0
@@ -77,21 +198,18 @@ func getCharColorType(v byte, isHexRepres bool) Type {
 	return TNonPrintable
 }
 
-// hexColorEncode is like HexEncode but with colors.
-func hexColorEncode(src []byte) ([]byte, int) {
-	j := 0
+// hexColorEncode is like HexEncode but with colors taken from theme.
+func hexColorEncode(src []byte, theme Theme) ([]byte, int) {
 	tmp := []byte{0x00, 0x00}
 	dst := make([]byte, 0, len(src)*3)
 
 	for _, v := range src {
 		cType := getCharColorType(v, true)
 
-		tmp[j] = hextable[v>>4]
-		tmp[j+1] = hextable[v&0x0f] // 0x0f => 0000 1111
+		tmp[0] = hextable[v>>4]
+		tmp[1] = hextable[v&0x0f] // 0x0f => 0000 1111
 
-		dst = append(dst, hexColor(cType, tmp)...)
-
-		j += 2
+		dst = append(dst, hexColor(theme, cType, tmp)...)
 	}
 
 	return dst, len(dst)
@@ -101,21 +219,36 @@ func hexColorEncode(src []byte) ([]byte, int) {
 // w. The format of the dump matches the output of `hexdump -C` on the command
 // line.
 func Dumper(w io.Writer, colorize bool) io.WriteCloser {
+	return NewDumper(w, HexDumpOptions{Theme: defaultTheme(colorize)})
+}
+
+// NewDumper returns a WriteCloser that writes a hex dump of all written data
+// to w, with the layout specified by opts.  Lines are written once they are
+// complete, the last one when the WriteCloser is closed.
+func NewDumper(w io.Writer, opts HexDumpOptions) io.WriteCloser {
+	return newDumper(w, opts.normalized())
+}
+
+// newDumper is like NewDumper but expects normalized options.
+func newDumper(w io.Writer, opts HexDumpOptions) *dumper {
 	return &dumper{
-		w:        w,
-		colorize: colorize,
-		buf:      make([]byte, 14),
+		w:    w,
+		opts: opts,
+		line: make([]byte, 0, opts.BytesPerLine),
 	}
 }
 
 type dumper struct {
-	w          io.Writer
-	rightChars [18]byte
-	buf        []byte
-	used       int  // number of bytes in the current line
-	n          uint // number of bytes, total
-	closed     bool
-	colorize   bool
+	w       io.Writer
+	opts    HexDumpOptions
+	line    []byte // bytes of the current line
+	prev    []byte // bytes of the previous line, if Squeeze is set
+	scratch []byte // rendered current line
+	n       uint64 // number of bytes of the completed lines
+	closed  bool
+
+	// squeezing is set while lines identical to prev are skipped.
+	squeezing bool
 }
 
 func toChar(b byte) byte {
@@ -126,140 +259,289 @@ func toChar(b byte) byte {
 	return b
 }
 
-var nOffset int
-
 func (h *dumper) Write(data []byte) (n int, err error) {
 	if h.closed {
 		return 0, errors.New("encoding/hex: dumper closed")
 	}
 
-	var dataPos int
-
-	// Output lines look like:
-	// 00000010  2e 2f 30 31 32 33 34 35  36 37 38 39 3a 3b 3c 3d  |./0123456789:;<=|
-	// ^ offset                          ^ extra space              ^ ASCII of line.
-	for i := range data {
-		if h.used == 0 {
-			// At the beginning of a line we print the current
-			// offset in hex.
-			h.buf[0] = byte(h.n >> 24)
-			h.buf[1] = byte(h.n >> 16)
-			h.buf[2] = byte(h.n >> 8)
-			h.buf[3] = byte(h.n)
-			// ignore returned dst since we disabled colorization
-			_, _ = HexEncode(h.buf[4:], h.buf[:4], false) // do not colorize offset
-			h.buf[12] = ' '
-			h.buf[13] = ' '
-
-			_, err = h.w.Write(h.buf[4:14])
-			if err != nil {
+	for len(data) > 0 {
+		free := h.opts.BytesPerLine - len(h.line)
+		if free > len(data) {
+			free = len(data)
+		}
+
+		h.line = append(h.line, data[:free]...)
+		data = data[free:]
+		n += free
+
+		if len(h.line) == h.opts.BytesPerLine {
+			if err = h.writeLine(); err != nil {
 				return
 			}
-
-			nOffset++
 		}
+	}
 
-		h.buf, _ = HexEncode(h.buf[:], data[i:i+1], h.colorize) // colorize, if enabled, the hex value (mid)
-		h.buf = append(h.buf, ' ')
+	return
+}
 
-		if h.used == 7 {
-			// There's an additional space after the 8th byte.
-			h.buf = append(h.buf, ' ')
-		} else if h.used == 15 {
-			// At the end of the line there's an extra space and
-			// the bar for the right column.
-			h.buf = append(h.buf, ' ', '|')
+// writeLine writes the current line and starts a new one.
+//
+// Output lines look like:
+// 00000010  2e 2f 30 31 32 33 34 35  36 37 38 39 3a 3b 3c 3d  |./0123456789:;<=|
+// ^ offset                          ^ extra space              ^ ASCII of line.
+func (h *dumper) writeLine() error {
+	if h.opts.Squeeze {
+		if h.prev != nil && bytes.Equal(h.line, h.prev) {
+			return h.skipLine()
 		}
 
-		_, err = h.w.Write(h.buf)
-		if err != nil {
-			return
-		}
+		h.squeezing = false
+		h.prev = append(h.prev[:0], h.line...)
+	}
 
-		h.rightChars[h.used] = toChar(data[i])
-		n++
-		h.used++
-		h.n++
+	h.scratch = h.appendOffset(h.scratch[:0])
+	h.scratch = append(h.scratch, ' ', ' ')
+	h.scratch = h.appendHex(h.scratch)
 
-		if h.used == 16 {
-			if h.colorize {
-				err = colorizeChars(h.w, data[dataPos:dataPos+16])
-				dataPos += 16
-			} else {
-				h.rightChars[16] = '|'
-				h.rightChars[17] = '\n'
+	if h.opts.HideASCII {
+		h.scratch = bytes.TrimRight(h.scratch, " ")
+	} else {
+		h.scratch = append(h.scratch, '|')
+		h.scratch = h.appendChars(h.scratch)
+		h.scratch = append(h.scratch, '|')
+	}
+	h.scratch = append(h.scratch, '\n')
 
-				_, err = h.w.Write(h.rightChars[:])
-			}
+	h.n += uint64(len(h.line))
+	h.line = h.line[:0]
 
-			if err != nil {
-				return
+	_, err := h.w.Write(h.scratch)
+
+	return err
+}
+
+// skipLine skips the current line, which is identical to the previous one.
+// The first skipped line of a run is replaced by "*".
+func (h *dumper) skipLine() error {
+	h.n += uint64(len(h.line))
+	h.line = h.line[:0]
+
+	if h.squeezing {
+		return nil
+	}
+	h.squeezing = true
+
+	_, err := h.w.Write(squeezeBytes)
+
+	return err
+}
+
+// appendOffset appends the offset of the current line in hex to dst.
+func (h *dumper) appendOffset(dst []byte) []byte {
+	digits := strconv.FormatUint(h.opts.BaseOffset+h.n, 16)
+	for i := len(digits); i < h.opts.OffsetWidth; i++ {
+		dst = append(dst, '0')
+	}
+
+	if h.opts.Uppercase {
+		digits = strings.ToUpper(digits)
+	}
+
+	return append(dst, digits...)
+}
+
+// appendHex appends the hex values of the current line to dst, padded to the
+// width of a complete line.
+func (h *dumper) appendHex(dst []byte) []byte {
+	digits := hextable
+	if h.opts.Uppercase {
+		digits = hextableUpper
+	}
+
+	tmp := []byte{0x00, 0x00}
+	for i := 0; i < h.opts.BytesPerLine; i++ {
+		if i < len(h.line) {
+			v := h.line[i]
+			tmp[0] = digits[v>>4]
+			tmp[1] = digits[v&0x0f] // 0x0f => 0000 1111
+
+			if h.opts.Theme != nil {
+				dst = append(dst, hexColor(h.opts.Theme, getCharColorType(v, true), tmp)...)
+			} else {
+				dst = append(dst, tmp...)
 			}
+			dst = append(dst, ' ')
+		} else {
+			dst = append(dst, ' ', ' ', ' ')
+		}
 
-			h.used = 0
+		// There's an additional space after every group and at the end of
+		// the line.
+		if i == h.opts.BytesPerLine-1 || h.opts.GroupSize > 0 && (i+1)%h.opts.GroupSize == 0 {
+			dst = append(dst, ' ')
 		}
 	}
 
-	return
+	return dst
 }
 
-func colorizeChars(w io.Writer, data []byte) error {
-	var err error
-	buf := make([]byte, 1)
-
-	for i := range data {
-		cType := getCharColorType(data[i], false)
+// appendChars appends the character column of the current line to dst.
+func (h *dumper) appendChars(dst []byte) []byte {
+	var buf []byte
+	var runeBuf [utf8.UTFMax]byte
+	cells := 0
+
+	for i := 0; i < len(h.line); {
+		cType := getCharColorType(h.line[i], false)
+		buf = append(runeBuf[:0], toChar(h.line[i]))
+		size := 1
+
+		if h.opts.UTF8 && h.line[i] >= utf8.RuneSelf {
+			r, n := utf8.DecodeRune(h.line[i:])
+			if r != utf8.RuneError && unicode.IsPrint(r) {
+				buf = runeBuf[:utf8.EncodeRune(runeBuf[:], r)]
+				cType = TPrintable
+				size = n
+			}
+		}
 
-		buf[0] = toChar(data[i])
-		_, err = w.Write(hexColor(cType, buf))
-		if err != nil {
-			return err
+		if h.opts.Theme != nil {
+			dst = append(dst, hexColor(h.opts.Theme, cType, buf)...)
+		} else {
+			dst = append(dst, buf...)
 		}
+
+		i += size
+		cells++
 	}
 
-	_, err = w.Write([]byte("|\n"))
+	// Multi-byte characters take a single cell, so pad the column to keep
+	// the closing bar aligned.
+	for ; cells < len(h.line); cells++ {
+		dst = append(dst, ' ')
+	}
 
-	return err
+	return dst
 }
 
 func (h *dumper) Close() (err error) {
-	// See the comments in Write() for the details of this format.
 	if h.closed {
 		return
 	}
 
 	h.closed = true
-	if h.used == 0 {
-		return
+	if len(h.line) > 0 {
+		return h.writeLine()
 	}
 
-	h.buf[0] = ' '
-	h.buf[1] = ' '
-	h.buf[2] = ' '
-	h.buf[3] = ' '
-	h.buf[4] = '|'
-	nBytes := h.used
+	// Mark the end of a run of skipped lines with its offset.
+	if h.squeezing {
+		h.scratch = append(h.appendOffset(h.scratch[:0]), '\n')
+		_, err = h.w.Write(h.scratch)
+	}
 
-	for h.used < 16 {
-		l := 3
+	return
+}
 
-		if h.used == 7 {
-			l = 4
-		} else if h.used == 15 {
-			l = 5
+// ansiEscapeRE matches the ANSI escape sequences used for colorization.
+var ansiEscapeRE = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// ParseHexDump reconstructs the bytes of a hex dump as written by Dumper,
+// NewDumper, HexDump or Dump.  Color escape sequences, indentation, the
+// character column and lines collapsed into "*" are handled, as are all
+// layouts HexDumpOptions produce.  The offsets have to be continuous; the
+// first one is the offset of the first returned byte.  Blank lines are
+// ignored.  Errors point at the offending line.
+func ParseHexDump(r io.Reader) ([]byte, error) {
+	var (
+		out      []byte
+		prev     []byte // bytes of the previous line
+		base     uint64 // offset of the first line
+		started  bool   // set once the first line was read
+		squeezed bool   // set after a "*" line
+		lineNo   int
+	)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(ansiEscapeRE.ReplaceAllString(sc.Text(), ""))
+		if line == "" {
+			continue
 		}
 
-		_, err = h.w.Write(h.buf[:l])
+		if line == "*" {
+			if prev == nil {
+				return nil, hexDumpError(lineNo, "repeated line marker without preceding line")
+			}
+			squeezed = true
+			continue
+		}
+
+		offStr, rest := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			offStr, rest = line[:i], line[i:]
+		}
+		off, err := strconv.ParseUint(offStr, 16, 64)
 		if err != nil {
-			return
+			return nil, hexDumpError(lineNo, "invalid offset %q", offStr)
+		}
+		if !started {
+			base, started = off, true
+		}
+
+		// Expand the run of lines collapsed into "*" up to this offset.
+		want := base + uint64(len(out))
+		if squeezed {
+			gap := off - want
+			if off < want || gap%uint64(len(prev)) != 0 {
+				return nil, hexDumpError(lineNo, "offset %x does not continue repeated lines of %d bytes from %x", off, len(prev), want)
+			}
+			for ; gap > 0; gap -= uint64(len(prev)) {
+				out = append(out, prev...)
+			}
+			squeezed = false
+		} else if off != want {
+			return nil, hexDumpError(lineNo, "offset %x, want %x", off, want)
+		}
+
+		// The character column starts at the first bar, which never
+		// occurs in the hex values.
+		if i := strings.IndexByte(rest, '|'); i >= 0 {
+			rest = rest[:i]
 		}
 
-		h.used++
+		fields := strings.Fields(rest)
+		lineBytes := make([]byte, len(fields))
+		for i, field := range fields {
+			b, err := strconv.ParseUint(field, 16, 8)
+			if err != nil || len(field) != 2 {
+				return nil, hexDumpError(lineNo, "invalid byte %q", field)
+			}
+			lineBytes[i] = byte(b)
+		}
+
+		// A line with just an offset ends the dump, so it can not be
+		// repeated.
+		prev = nil
+		if len(lineBytes) > 0 {
+			prev = lineBytes
+		}
+		out = append(out, lineBytes...)
 	}
 
-	h.rightChars[nBytes] = '|'
-	h.rightChars[nBytes+1] = '\n'
-	_, err = h.w.Write(h.rightChars[:nBytes+2])
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
 
-	return
+	if squeezed {
+		return nil, hexDumpError(lineNo, "repeated lines without end offset")
+	}
+
+	return out, nil
+}
+
+// hexDumpError returns an error of ParseHexDump for line lineNo.
+func hexDumpError(lineNo int, format string, a ...interface{}) error {
+	return fmt.Errorf("spew: hex dump line %d: %s", lineNo, fmt.Sprintf(format, a...))
 }
@@ -0,0 +1,187 @@
+package spew_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// TestHexDumpWith ensures the layout of hex dumps follows the passed
+// HexDumpOptions.
+func TestHexDumpWith(t *testing.T) {
+	data := []byte("0123456789abcdefXYZ")
+	utf := []byte("héllo wörld\x00")
+
+	tests := []struct {
+		opts spew.HexDumpOptions
+		data []byte
+		want string
+	}{
+		{spew.HexDumpOptions{}, data,
+			"00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+				"00000010  58 59 5a                                          |XYZ|\n"},
+		{spew.HexDumpOptions{BytesPerLine: 8, GroupSize: 4}, data[:12],
+			"00000000  30 31 32 33  34 35 36 37  |01234567|\n" +
+				"00000008  38 39 61 62               |89ab|\n"},
+		{spew.HexDumpOptions{BytesPerLine: 4, GroupSize: -1, Uppercase: true, HideASCII: true}, []byte{0xab, 0xcd, 0xef, 0x01, 0x23},
+			"00000000  AB CD EF 01\n" +
+				"00000004  23\n"},
+		{spew.HexDumpOptions{BytesPerLine: 4, OffsetWidth: 4, BaseOffset: 0xffffffff}, data[:5],
+			"ffffffff  30 31 32 33  |0123|\n" +
+				"100000003  34           |4|\n"},
+		{spew.HexDumpOptions{UTF8: true}, utf,
+			"00000000  68 c3 a9 6c 6c 6f 20 77  c3 b6 72 6c 64 00        |héllo wörld.  |\n"},
+		{spew.HexDumpOptions{}, utf,
+			"00000000  68 c3 a9 6c 6c 6f 20 77  c3 b6 72 6c 64 00        |h..llo w..rld.|\n"},
+		{spew.HexDumpOptions{}, nil, ""},
+	}
+
+	for i, test := range tests {
+		if got := spew.HexDumpWith(test.data, test.opts); got != test.want {
+			t.Errorf("HexDumpWith #%d\n got: %s want: %s", i, got, test.want)
+		}
+	}
+}
+
+// TestNewDumper ensures the streaming dumper produces the same output as
+// HexDumpWith regardless of how the data is split into writes.
+func TestNewDumper(t *testing.T) {
+	data := make([]byte, 50)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	opts := spew.HexDumpOptions{BytesPerLine: 12, GroupSize: 3}
+	want := spew.HexDumpWith(data, opts)
+
+	for _, chunk := range []int{1, 5, 12, 13, 50} {
+		var buf bytes.Buffer
+		d := spew.NewDumper(&buf, opts)
+		for i := 0; i < len(data); i += chunk {
+			end := i + chunk
+			if end > len(data) {
+				end = len(data)
+			}
+			d.Write(data[i:end])
+		}
+		d.Close()
+
+		if got := buf.String(); got != want {
+			t.Errorf("NewDumper chunk %d\n got: %s want: %s", chunk, got, want)
+		}
+	}
+
+	if got := spew.HexDump(data, false); got != spew.HexDumpWith(data, spew.HexDumpOptions{}) {
+		t.Errorf("HexDump\n got: %s want: %s", got, spew.HexDumpWith(data, spew.HexDumpOptions{}))
+	}
+
+	cs := &spew.ConfigState{Indent: " ", HexDumpOptions: spew.HexDumpOptions{BytesPerLine: 4, HideASCII: true}}
+	wantDump := "([]uint8) (len=6 cap=6) {\n" +
+		" 00000000  00 07 0e 15\n" +
+		" 00000004  1c 23\n" +
+		"}\n"
+	if got := cs.Sdump(data[:6:6]); got != wantDump {
+		t.Errorf("Sdump\n got: %s want: %s", got, wantDump)
+	}
+}
+
+// TestHexDumpSqueeze ensures runs of identical lines are collapsed into a "*"
+// line, also when the data is written in several chunks.
+func TestHexDumpSqueeze(t *testing.T) {
+	opts := spew.HexDumpOptions{BytesPerLine: 4, Squeeze: true}
+
+	frame := append([]byte("abcd"), make([]byte, 13)...)
+	frame = append(frame, "wxyz"...)
+	padded := append([]byte("abcd"), make([]byte, 12)...)
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{frame, "00000000  61 62 63 64  |abcd|\n" +
+			"00000004  00 00 00 00  |....|\n" +
+			"*\n" +
+			"00000010  00 77 78 79  |.wxy|\n" +
+			"00000014  7a           |z|\n"},
+		{padded, "00000000  61 62 63 64  |abcd|\n" +
+			"00000004  00 00 00 00  |....|\n" +
+			"*\n" +
+			"00000010\n"},
+		{[]byte("abcdabcd"), "00000000  61 62 63 64  |abcd|\n" +
+			"*\n" +
+			"00000008\n"},
+	}
+
+	for i, test := range tests {
+		if got := spew.HexDumpWith(test.data, opts); got != test.want {
+			t.Errorf("HexDumpSqueeze #%d\n got: %s want: %s", i, got, test.want)
+		}
+
+		var buf bytes.Buffer
+		d := spew.NewDumper(&buf, opts)
+		for _, b := range test.data {
+			d.Write([]byte{b})
+		}
+		d.Close()
+		if got := buf.String(); got != test.want {
+			t.Errorf("HexDumpSqueeze streaming #%d\n got: %s want: %s", i, got, test.want)
+		}
+	}
+}
+
+// TestParseHexDump ensures hex dumps in all supported layouts are parsed back
+// into the exact bytes, and malformed ones are reported with their line.
+func TestParseHexDump(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	zeros := append(make([]byte, 70), 1, 2, 3)
+
+	dump := spew.ConfigState{Indent: "\t", HighlightValues: true, HighlightHex: true, ColorMode: spew.ColorAlways}
+	sdump := dump.Sdump(data)
+	sdump = sdump[strings.Index(sdump, "\n")+1 : strings.LastIndex(sdump, "}")]
+
+	valid := []struct {
+		dump string
+		want []byte
+	}{
+		{spew.HexDump(data, false), data},
+		{spew.HexDump(data, true), data},
+		{sdump, data},
+		{spew.HexDumpWith(data, spew.HexDumpOptions{BytesPerLine: 7, GroupSize: -1, Uppercase: true, HideASCII: true}), data},
+		{spew.HexDumpWith(data, spew.HexDumpOptions{BaseOffset: 0x1000, UTF8: true}), data},
+		{spew.HexDumpWith(zeros, spew.HexDumpOptions{Squeeze: true}), zeros},
+		{spew.HexDumpWith(zeros[:64], spew.HexDumpOptions{Squeeze: true}), zeros[:64]},
+		{"00000000  7c 7c  ||||\n00000002\n", []byte("||")},
+		{"", nil},
+	}
+
+	for i, test := range valid {
+		got, err := spew.ParseHexDump(strings.NewReader(test.dump))
+		if err != nil || !bytes.Equal(got, test.want) {
+			t.Errorf("ParseHexDump #%d\n got: %v, %v\nwant: %v", i, got, err, test.want)
+		}
+	}
+
+	invalid := []struct {
+		dump string
+		want string
+	}{
+		{"00000000  00 01\nzz  00\n", "spew: hex dump line 2: invalid offset \"zz\""},
+		{"00000000  00 01\n\n00000003  02\n", "spew: hex dump line 3: offset 3, want 2"},
+		{"00000000  00 0g\n", "spew: hex dump line 1: invalid byte \"0g\""},
+		{"*\n", "spew: hex dump line 1: repeated line marker without preceding line"},
+		{"00000000  00 01\n*\n00000005  02\n", "spew: hex dump line 3: offset 5 does not continue repeated lines of 2 bytes from 2"},
+		{"00000000  00 01\n*\n", "spew: hex dump line 2: repeated lines without end offset"},
+	}
+
+	for i, test := range invalid {
+		_, err := spew.ParseHexDump(strings.NewReader(test.dump))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseHexDump invalid #%d\n got: %v\nwant: %s", i, err, test.want)
+		}
+	}
+}
@@ -24,8 +24,15 @@ package spew
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
+	"time"
+
+	gcolor "github.com/gookit/color"
 )
 
 // dummyFmtState implements a fake fmt.State to use for testing invalid
@@ -82,3 +89,310 @@ func TestInvalidReflectValue(t *testing.T) {
 func SortValues(values []reflect.Value, cs *ConfigState) {
 	sortValues(values, cs)
 }
+
+// markerPrinter is a ColorPrinter which wraps the printed text in the name of
+// the color type instead of ANSI escape codes, so colorized output can be
+// tested independently of the terminal.
+type markerPrinter string
+
+func (m markerPrinter) Sprint(a ...interface{}) string {
+	return "<" + string(m) + ">" + fmt.Sprint(a...) + "</" + string(m) + ">"
+}
+
+// markerTheme is a Theme using a markerPrinter for every color type the dump
+// and formatter code uses.
+var markerTheme = Theme{
+	TInteger:    markerPrinter("int"),
+	TFloat:      markerPrinter("float"),
+	TString:     markerPrinter("str"),
+	TBool:       markerPrinter("bool"),
+	TNil:        markerPrinter("nil"),
+	TTInteger:   markerPrinter("tint"),
+	TTString:    markerPrinter("tstr"),
+	TTPtr:       markerPrinter("tptr"),
+	TTAddress:   markerPrinter("addr"),
+	TTInterface: markerPrinter("tiface"),
+	TCircular:   markerPrinter("circ"),
+	TMaxDepth:   markerPrinter("max"),
+}
+
+// TestFormatterHighlightValues ensures the formatter colorizes values, types,
+// pointer addresses and special markers.
+func TestFormatterHighlightValues(t *testing.T) {
+	type circular struct {
+		I int
+		C *circular
+	}
+	c := &circular{I: 1}
+	c.C = c
+	cAddr := fmt.Sprintf("%p", c)
+
+	cs := &ConfigState{HighlightValues: true, ColorMode: ColorAlways, Theme: markerTheme}
+	csMax := &ConfigState{HighlightValues: true, ColorMode: ColorAlways, Theme: markerTheme, MaxDepth: 1}
+	tests := []struct {
+		cs     *ConfigState
+		format string
+		in     interface{}
+		want   string
+	}{
+		{cs, "%v", 5, "<int>5</int>"},
+		{cs, "%#v", 5, "(<tint>int</tint>)<int>5</int>"},
+		{cs, "%v", "s", "<str>s</str>"},
+		{cs, "%v", nil, "<nil><nil></nil>"},
+		{cs, "%#v", (*int)(nil), "(<tptr>*int</tptr>)<nil><nil></nil>"},
+		{cs, "%v", []interface{}{true, nil}, "[<bool>true</bool> <nil><nil></nil>]"},
+		{cs, "%v", c, "<<tptr>*</tptr>>{<int>1</int> <<tptr>*</tptr>><circ><shown></circ>}"},
+		{cs, "%+v", c, "<<tptr>*</tptr>>(<addr>" + cAddr + "</addr>){I:<int>1</int> " +
+			"C:<<tptr>*</tptr>>(<addr>" + cAddr + "</addr>)<circ><shown></circ>}"},
+		{csMax, "%v", [][]int{{1}}, "[[<max><max></max>]]"},
+		{&ConfigState{}, "%#v", 5, "(int)5"},
+	}
+
+	for i, test := range tests {
+		s := test.cs.Sprintf(test.format, test.in)
+		if s != test.want {
+			t.Errorf("FormatterHighlightValues #%d\n got: %s want: %s", i, s, test.want)
+		}
+	}
+}
+
+// TestHighlightDelimiters ensures dumps and the formatter colorize time values,
+// map and struct delimiters and struct field names.
+func TestHighlightDelimiters(t *testing.T) {
+	type record struct {
+		At   time.Time
+		Tags map[string]int
+	}
+	r := record{
+		At:   time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Tags: map[string]int{"a": 1},
+	}
+
+	cs := &ConfigState{
+		Indent:          " ",
+		HighlightValues: true,
+		ColorMode:       ColorAlways,
+		Theme: markerTheme.With(Theme{
+			TMap:       markerPrinter("map"),
+			TDate:      markerPrinter("date"),
+			TStruct:    markerPrinter("struct"),
+			TTDate:     markerPrinter("tdate"),
+			TFieldName: markerPrinter("field"),
+		}),
+	}
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{cs.Sdump(r), "(spew.record) <struct>{</struct>\n" +
+			" <field>At</field>: (<tdate>time.Time</tdate>) <date>2021-03-04T05:06:07Z</date>,\n" +
+			" <field>Tags</field>: (map[string]int) (len=1) <map>{</map>\n" +
+			"  (<tstr>string</tstr>) (len=1) <str>\"a\"</str>: (<tint>int</tint>) <int>1</int>\n" +
+			" <map>}</map>\n" +
+			"<struct>}</struct>\n"},
+		{cs.Sdump(&r.At), "(<tdate>*</tdate><tdate>time.Time</tdate>)(<addr>" + fmt.Sprintf("%p", &r.At) +
+			"</addr>)(<date>2021-03-04T05:06:07Z</date>)\n"},
+		{cs.Sdump(time.Second), "(<tdate>time.Duration</tdate>) <date>1s</date>\n"},
+		{cs.Sprintf("%+v", r), "<struct>{</struct><field>At</field>:<date>2021-03-04T05:06:07Z</date> " +
+			"<field>Tags</field>:<map>map[</map><str>a</str>:<int>1</int><map>]</map><struct>}</struct>"},
+		{cs.Sprintf("%#v", time.Second), "(<tdate>time.Duration</tdate>)<date>1s</date>"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("HighlightDelimiters #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+}
+
+// TestPrinterHighlight ensures the output of custom formatters is colorized
+// like the values spew renders itself.
+func TestPrinterHighlight(t *testing.T) {
+	type pair struct{ a, b int }
+
+	cs := &ConfigState{
+		Indent:          " ",
+		HighlightValues: true,
+		ColorMode:       ColorAlways,
+		Theme: markerTheme.With(Theme{
+			TStruct:    markerPrinter("struct"),
+			TFieldName: markerPrinter("field"),
+		}),
+	}
+	cs.RegisterFormatter(reflect.TypeOf(pair{}), func(p *Printer, v reflect.Value) {
+		p.Field("a", v.Field(0))
+		p.Elem(v.Field(1))
+	})
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{cs.Sdump(pair{1, 2}), "(spew.pair) <struct>{</struct>\n" +
+			" <field>a</field>: (<tint>int</tint>) <int>1</int>,\n" +
+			" (<tint>int</tint>) <int>2</int>\n" +
+			"<struct>}</struct>\n"},
+		{cs.Sprintf("%+v", pair{1, 2}), "<struct>{</struct><field>a</field>:<int>1</int> <int>2</int><struct>}</struct>"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("PrinterHighlight #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+}
+
+// fakeEnv replaces the environment used for color detection with env until
+// the returned function is called.
+func fakeEnv(env map[string]string) (restore func()) {
+	orig := lookupEnv
+	lookupEnv = func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+	return func() { lookupEnv = orig }
+}
+
+// TestColorLevel ensures the color mode, the destination and the environment
+// are respected when deciding whether and how many colors are emitted.
+func TestColorLevel(t *testing.T) {
+	// a temporary file is never a terminal
+	file, err := ioutil.TempFile("", "spew-color")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// neither is the null device, although it is a character device
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	xterm := map[string]string{"TERM": "xterm"}
+	xterm256 := map[string]string{"TERM": "xterm-256color"}
+	truecolor := map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}
+	tests := []struct {
+		mode ColorMode
+		hl   bool
+		env  map[string]string
+		w    io.Writer
+		want colorLevel
+	}{
+		{ColorAlways, false, xterm, nil, levelNone},
+		{ColorNever, true, xterm, nil, levelNone},
+		{ColorAlways, true, nil, nil, levelTrueColor},
+		{ColorAlways, true, xterm, nil, level16},
+		{ColorAlways, true, xterm256, new(bytes.Buffer), level256},
+		{ColorAlways, true, truecolor, file, levelTrueColor},
+		{ColorAlways, true, map[string]string{"NO_COLOR": "1"}, nil, levelTrueColor},
+		{ColorAuto, true, xterm, new(bytes.Buffer), levelNone},
+		{ColorAuto, true, xterm, file, levelNone},
+		{ColorAuto, true, xterm, devNull, levelNone},
+		{ColorAuto, true, xterm, nil, levelNone},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": ""}, nil, level16},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": "1", "TERM": "dumb"}, nil, level16},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": "2"}, file, level256},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": "3"}, file, levelTrueColor},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": "true", "COLORTERM": "24bit"}, nil, levelTrueColor},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": "0"}, nil, levelNone},
+		{ColorAuto, true, map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, nil, levelNone},
+	}
+
+	for i, test := range tests {
+		restore := fakeEnv(test.env)
+		cs := &ConfigState{HighlightValues: test.hl, ColorMode: test.mode}
+		lvl := cs.colorLevel(test.w)
+		restore()
+
+		if lvl != test.want {
+			t.Errorf("ColorLevel #%d got: %d want: %d", i, lvl, test.want)
+		}
+	}
+}
+
+// TestDownsampleCode ensures true color and 256 color codes are converted to
+// the closest code supported by the destination.
+func TestDownsampleCode(t *testing.T) {
+	tests := []struct {
+		code string
+		lvl  colorLevel
+		want string
+	}{
+		{"38;2;255;0;0", levelTrueColor, "38;2;255;0;0"},
+		{"38;2;255;0;0", level256, "38;5;9"},
+		{"38;2;130;170;255", level16, "94"},
+		{"38;2;255;0;0", level16, "91"},
+		{"48;2;0;0;0", level16, "40"},
+		{"38;2;255;255;255;48;2;0;0;0;1", level16, "97;40;1"},
+		{"38;5;196", level256, "38;5;196"},
+		{"38;5;196", level16, "91"},
+		{"48;5;4", level16, "44"},
+		{"32;1", level16, "32;1"},
+	}
+
+	for i, test := range tests {
+		if code := downsampleCode(test.code, test.lvl); code != test.want {
+			t.Errorf("DownsampleCode #%d got: %s want: %s", i, code, test.want)
+		}
+	}
+}
+
+// TestColorModeDestination ensures Sdump and the Sprint family are not
+// colorized in the default mode, while ColorAlways colorizes them.
+func TestColorModeDestination(t *testing.T) {
+	defer fakeEnv(map[string]string{"TERM": "xterm"})()
+
+	theme := Theme{TInteger: gcolor.Blue}
+	auto := &ConfigState{HighlightValues: true, Theme: theme}
+	always := &ConfigState{HighlightValues: true, Theme: theme, ColorMode: ColorAlways}
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{auto.Sdump(1), "(int) 1\n"},
+		{auto.Sprint(1), "1"},
+		{always.Sdump(1), "(int) \x1b[34m1\x1b[0m\n"},
+		{always.Sprint(1), "\x1b[34m1\x1b[0m"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("ColorModeDestination #%d got: %q want: %q", i, test.got, test.want)
+		}
+	}
+}
+
+// TestMatchPattern ensures the '*' wildcard of field rule patterns matches
+// any sequence of characters.
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"*", "", true},
+		{"*", ".A[0].B", true},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"a*c", "abbbc", true},
+		{"a*c", "abbbd", false},
+		{"*.Token", ".Users[0].Token", true},
+		{"*.Token", ".Users[0].TokenID", false},
+		{".Users[*].*", ".Users[12].Name", true},
+		{"*a*a*", "banana", true},
+		{"*x*", "banana", false},
+	}
+
+	for i, test := range tests {
+		if got := matchPattern(test.pattern, test.s); got != test.want {
+			t.Errorf("MatchPattern #%d %q %q: got %v want %v", i, test.pattern, test.s, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package spew
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// jsonNode is the JSON representation of a single value of a dump.  Every
+// piece of information Dump prints has its own member, members which do not
+// apply to the value are omitted.
+type jsonNode struct {
+	Type     string      `json:"type,omitempty"`
+	Len      int         `json:"len,omitempty"`
+	Cap      int         `json:"cap,omitempty"`
+	Pointers []string    `json:"pointers,omitempty"`
+	Nil      bool        `json:"nil,omitempty"`
+	Invalid  bool        `json:"invalid,omitempty"`
+	Circular bool        `json:"circular,omitempty"`
+	Ref      string      `json:"ref,omitempty"`
+	Redacted bool        `json:"redacted,omitempty"`
+	MaxDepth bool        `json:"maxDepth,omitempty"`
+	Stringer string      `json:"stringer,omitempty"`
+	Panic    string      `json:"panic,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+	Bytes    []byte      `json:"bytes,omitempty"`
+	Tail     []byte      `json:"tail,omitempty"`
+	HexDump  string      `json:"hexdump,omitempty"`
+	Elems    []*jsonNode `json:"elems,omitempty"`
+	Entries  []jsonEntry `json:"entries,omitempty"`
+	Fields   []jsonField `json:"fields,omitempty"`
+	More     int         `json:"more,omitempty"`
+}
+
+// jsonEntry is the JSON representation of a map entry.
+type jsonEntry struct {
+	Key   *jsonNode `json:"key"`
+	Value *jsonNode `json:"value"`
+}
+
+// jsonField is the JSON representation of a struct field.
+type jsonField struct {
+	Name  string    `json:"name"`
+	Value *jsonNode `json:"value"`
+}
+
+// newJSONNode returns the JSON representation of the node n returned by Walk
+// with the configuration cs.
+func newJSONNode(cs *ConfigState, n *Node) *jsonNode {
+	if n.Invalid {
+		return &jsonNode{Invalid: true}
+	}
+	if n.Redacted {
+		return &jsonNode{Redacted: true}
+	}
+
+	j := &jsonNode{
+		Type:     n.Type,
+		Len:      n.Len,
+		Nil:      n.Nil,
+		Circular: n.Circular,
+		Ref:      n.Ref,
+		MaxDepth: n.MaxDepth,
+		Stringer: n.Method,
+		Panic:    n.Panic,
+		More:     n.More,
+	}
+	if !cs.DisableCapacities {
+		j.Cap = n.Cap
+	}
+	if cs.PointerIDs || !cs.DisablePointerAddresses {
+		j.Pointers = n.Addrs
+	}
+	j.setBytes(cs, n.Bytes)
+	j.setValue(cs, n)
+
+	for _, c := range n.Children {
+		switch {
+		case c.Key != nil:
+			j.Entries = append(j.Entries, jsonEntry{Key: newJSONNode(cs, c.Key), Value: newJSONNode(cs, c)})
+		case c.Name != "":
+			j.Fields = append(j.Fields, jsonField{Name: c.Name, Value: newJSONNode(cs, c)})
+		default:
+			j.Elems = append(j.Elems, newJSONNode(cs, c))
+		}
+	}
+
+	return j
+}
+
+// setBytes records the contents of a hex dumped array or slice in j.  If
+// they are longer than MaxBytes, only the first and last bytes Dump shows are
+// recorded in Bytes and Tail, and the number of omitted ones in More.
+func (j *jsonNode) setBytes(cs *ConfigState, buf []byte) {
+	if len(buf) == 0 {
+		return
+	}
+
+	j.Bytes = buf
+	if cs.MaxBytes > 0 && len(buf) > cs.MaxBytes {
+		head, tail := byteWindows(len(buf), cs.MaxBytes)
+		j.Bytes, j.Tail, j.More = buf[:head], buf[tail:], tail-head
+	}
+
+	if cs.JSONHexDump {
+		opts := cs.HexDumpOptions
+		opts.Theme = nil
+		j.HexDump = hexDumpWindows(buf, cs.MaxBytes, opts)
+	}
+}
+
+// setValue records the value of the node n in j as a JSON boolean, number or
+// string depending on its kind.  Values which are not displayed, such as the
+// ones shown by their Error or String method, are left out.
+func (j *jsonNode) setValue(cs *ConfigState, n *Node) {
+	switch {
+	case n.Nil || n.Circular || n.Ref != "":
+		return
+
+	// The text of custom formatters and time values is always a string.
+	case n.custom != nil || n.time:
+		if n.Value != "" {
+			j.Value = n.Value
+		}
+		return
+
+	case n.call != nil && !n.call.panicked && !cs.ContinueOnMethod:
+		return
+	}
+
+	switch n.Kind {
+	case reflect.Bool:
+		j.Value = n.Value == "true"
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		j.Value = json.Number(n.Value)
+
+	case reflect.Float32, reflect.Float64:
+		j.Value = jsonFloat(n.Value)
+
+	case reflect.Uintptr, reflect.UnsafePointer, reflect.Chan, reflect.Func:
+		if n.Value == string(nilAngleBytes) {
+			j.Nil = true
+			break
+		}
+		j.Value = n.Value
+
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct, reflect.Interface:
+		// These are described by their children.
+
+	default:
+		j.Value = n.Value
+	}
+}
+
+// jsonFloat returns the JSON value of the formatted floating point value str.
+// Values not representable as JSON numbers (NaN and infinities) are returned
+// as string.
+func jsonFloat(str string) interface{} {
+	switch str {
+	case "NaN", "+Inf", "-Inf":
+		return str
+	}
+
+	return json.Number(str)
+}
+
+// fjson is a helper function to consolidate the logic from the various public
+// methods which take varying writers and config states.
+func fjson(cs *ConfigState, w io.Writer, a ...interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for _, arg := range a {
+		if err := enc.Encode(newJSONNode(cs, Walk(cs, arg))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fjson writes the passed arguments to io.Writer w as JSON.  It describes
+// exactly the same as Sjson.  The only possible errors are write errors.
+func Fjson(w io.Writer, a ...interface{}) error {
+	return fjson(&Config, w, a...)
+}
+
+/*
+Sjson returns a JSON document for each of the passed arguments, describing the
+same tree Dump displays, which is the one returned by Walk.  The documents are
+separated by newlines, so they can be shipped directly to log pipelines which
+index JSON lines.
+
+Every value is an object with the following members, which are omitted if they
+do not apply:
+
+	type      the type of the value, including the level of indirection
+	len, cap  the length and capacity as shown by Dump
+	pointers  the chain of pointer addresses used to reach the value, or
+	          their ids if PointerIDs is set
+	nil       true for nil pointers, interfaces, maps, slices and the like
+	circular  true if the value was already shown (circular reference)
+	ref       the id or path of the value pointed to if it was already
+	          shown, see PointerIDs and DetectSharedPointers
+	redacted  true if the value of a struct field was redacted
+	maxDepth  true if the children were omitted due to MaxDepth
+	stringer  the result of the Error or String method
+	panic     the panic raised by the Error or String method
+	value     the value of scalar types, or the text written by custom
+	          formatters and SpewDumper implementations
+	bytes     the contents of byte arrays and slices, base64 encoded, or
+	          only the first ones Dump shows if MaxBytes cuts them
+	tail      the last bytes Dump shows if MaxBytes cuts them, base64
+	          encoded
+	hexdump   the hexdump -C style dump of byte arrays and slices, if
+	          JSONHexDump is set
+	elems     the elements of arrays and slices, and the values passed to
+	          Printer.Elem and Printer.Value
+	entries   the entries of maps as objects with a key and a value member
+	fields    the fields of structs, and the values passed to Printer.Field,
+	          as objects with a name and a value member
+	more      the number of elements, or bytes of strings and byte slices,
+	          omitted due to MaxElements, MaxStringLen or MaxBytes
+
+The configuration options are controlled by an exported package global,
+spew.Config.  See ConfigState for options documentation.
+*/
+func Sjson(a ...interface{}) string {
+	var buf bytes.Buffer
+	fjson(&Config, &buf, a...)
+	return buf.String()
+}
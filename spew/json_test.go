@@ -0,0 +1,184 @@
+package spew_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// jsonTest is used to describe a test to be performed against the Sjson
+// method.
+type jsonTest struct {
+	cs   *spew.ConfigState
+	in   interface{}
+	want string
+}
+
+type jsonRecord struct {
+	Name  string
+	Data  []byte
+	Tags  map[string]int
+	Next  *jsonRecord
+	At    time.Time
+	Flag  stringer
+	inner []interface{}
+}
+
+// TestSjson ensures Sjson describes the same tree as Dump.
+func TestSjson(t *testing.T) {
+	cs := &spew.ConfigState{SortKeys: true}
+	csNoAddr := &spew.ConfigState{SortKeys: true, DisablePointerAddresses: true, DisableCapacities: true}
+	csHex := &spew.ConfigState{JSONHexDump: true}
+	csMax := &spew.ConfigState{MaxDepth: 1}
+	csNoMethods := &spew.ConfigState{DisableMethods: true}
+	csContinue := &spew.ConfigState{ContinueOnMethod: true}
+
+	v := 5
+	pv := &v
+	vAddr := fmt.Sprintf("%p", pv)
+	pvAddr := fmt.Sprintf("%p", &pv)
+
+	r := &jsonRecord{
+		Name:  "a",
+		Data:  []byte{1, 2},
+		Tags:  map[string]int{"y": 2, "x": 1},
+		At:    time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Flag:  "f",
+		inner: []interface{}{nil, 1.5},
+	}
+	r.Next = r
+
+	tests := []jsonTest{
+		{cs, nil, `{"type":"interface {}","nil":true}`},
+		{cs, 5, `{"type":"int","value":5}`},
+		{cs, uint64(math.MaxUint64), `{"type":"uint64","value":18446744073709551615}`},
+		{cs, math.Inf(1), `{"type":"float64","value":"+Inf"}`},
+		{cs, complex(1, -2), `{"type":"complex128","value":"(1-2i)"}`},
+		{cs, "a<b", `{"type":"string","len":3,"value":"a<b"}`},
+		{cs, true, `{"type":"bool","value":true}`},
+		{cs, &pv, `{"type":"**int","pointers":["` + pvAddr + `","` + vAddr + `"],"value":5}`},
+		{cs, (*int)(nil), `{"type":"*int","nil":true}`},
+		{cs, []int(nil), `{"type":"[]int","nil":true}`},
+		{cs, make([]int, 1, 2), `{"type":"[]int","len":1,"cap":2,"elems":[{"type":"int","value":0}]}`},
+		{csNoAddr, make([]int, 1, 2), `{"type":"[]int","len":1,"elems":[{"type":"int","value":0}]}`},
+		{cs, []byte{1, 2}, `{"type":"[]uint8","len":2,"cap":2,"bytes":"AQI="}`},
+		{csHex, []byte{'h', 'i'}, `{"type":"[]uint8","len":2,"cap":2,"bytes":"aGk=",` +
+			`"hexdump":"00000000  68 69                                             |hi|\n"}`},
+		{cs, stringer("s"), `{"type":"spew_test.stringer","len":1,"stringer":"stringer s"}`},
+		{csNoMethods, stringer("s"), `{"type":"spew_test.stringer","len":1,"value":"s"}`},
+		{csContinue, customError(1), `{"type":"spew_test.customError","stringer":"error: 1","value":1}`},
+		{cs, panicer(1), `{"type":"spew_test.panicer","panic":"test panic","value":1}`},
+		{cs, time.Second, `{"type":"time.Duration","value":"1s"}`},
+		{csMax, [][]int{{1}}, `{"type":"[][]int","len":1,"cap":1,"elems":[` +
+			`{"type":"[]int","len":1,"cap":1,"maxDepth":true}]}`},
+		{csNoAddr, r, `{"type":"*spew_test.jsonRecord","fields":[` +
+			`{"name":"Name","value":{"type":"string","len":1,"value":"a"}},` +
+			`{"name":"Data","value":{"type":"[]uint8","len":2,"bytes":"AQI="}},` +
+			`{"name":"Tags","value":{"type":"map[string]int","len":2,"entries":[` +
+			`{"key":{"type":"string","len":1,"value":"x"},"value":{"type":"int","value":1}},` +
+			`{"key":{"type":"string","len":1,"value":"y"},"value":{"type":"int","value":2}}]}},` +
+			`{"name":"Next","value":{"type":"*spew_test.jsonRecord","circular":true}},` +
+			`{"name":"At","value":{"type":"time.Time","value":"2021-03-04T05:06:07Z"}},` +
+			`{"name":"Flag","value":{"type":"spew_test.stringer","len":1,"stringer":"stringer f"}},` +
+			`{"name":"inner","value":{"type":"[]interface {}","len":2,"elems":[` +
+			`{"type":"interface {}","nil":true},{"type":"float64","value":1.5}]}}]}`},
+	}
+
+	for i, test := range tests {
+		s := test.cs.Sjson(test.in)
+		if s != test.want+"\n" {
+			t.Errorf("Sjson #%d\n got: %s want: %s", i, s, test.want)
+			continue
+		}
+
+		if !json.Valid([]byte(s)) {
+			t.Errorf("Sjson #%d produced invalid JSON: %s", i, s)
+		}
+	}
+}
+
+// panicText is a Stringer whose result looks like the panic of a method.
+type panicText int
+
+func (p panicText) String() string {
+	return "(PANIC=not a panic)"
+}
+
+// TestSjsonOptions ensures Sjson applies the limits, pointer ids, custom
+// formatters and SpewDumper implementations like Dump.
+func TestSjsonOptions(t *testing.T) {
+	cs := &spew.ConfigState{SortKeys: true}
+	limits := &spew.ConfigState{SortKeys: true, MaxElements: 2, MaxStringLen: 2, MaxBytes: 2, JSONHexDump: true}
+	ids := &spew.ConfigState{PointerIDs: true, DisablePointerAddresses: true}
+	shared := &spew.ConfigState{DetectSharedPointers: true, DisablePointerAddresses: true}
+	printers := newPrinterConfig()
+
+	v := 5
+	pv := &v
+
+	bob := &person{name: "bob"}
+	acc := account{id: 7, owner: bob}
+	bob.account = &acc
+
+	tests := []jsonTest{
+		{cs, panicText(1), `{"type":"spew_test.panicText","stringer":"(PANIC=not a panic)"}`},
+		{limits, []int{1, 2, 3}, `{"type":"[]int","len":3,"cap":3,"elems":[` +
+			`{"type":"int","value":1},{"type":"int","value":2}],"more":1}`},
+		{limits, map[string]int{"a": 1, "b": 2, "c": 3}, `{"type":"map[string]int","len":3,"entries":[` +
+			`{"key":{"type":"string","len":1,"value":"a"},"value":{"type":"int","value":1}},` +
+			`{"key":{"type":"string","len":1,"value":"b"},"value":{"type":"int","value":2}}],"more":1}`},
+		{limits, "héllo", `{"type":"string","len":6,"value":"h","more":5}`},
+		{limits, []byte("hello"), `{"type":"[]uint8","len":5,"cap":5,"bytes":"aA==","tail":"bw==",` +
+			`"hexdump":"00000000  68                                                |h|\n` +
+			`... 3 bytes omitted\n` +
+			`00000004  6f                                                |o|\n","more":3}`},
+		{ids, []*int{pv, pv}, `{"type":"[]*int","len":2,"cap":2,"elems":[` +
+			`{"type":"*int","pointers":["&1"],"value":5},{"type":"*int","ref":"&1"}]}`},
+		{shared, []*int{pv, pv}, `{"type":"[]*int","len":2,"cap":2,"elems":[` +
+			`{"type":"*int","value":5},{"type":"*int","ref":"[0]"}]}`},
+		{printers, money{150}, `{"type":"spew_test.money","value":"1.50"}`},
+		{printers, node{name: "a", children: []*node{{name: "b"}}}, `{"type":"spew_test.node",` +
+			`"elems":[{"type":"*spew_test.node","fields":[{"name":"name","value":{"type":"string","len":1,"value":"b"}}]}],` +
+			`"fields":[{"name":"name","value":{"type":"string","len":1,"value":"a"}}]}`},
+		{printers, acc, `{"type":"spew_test.account","fields":[` +
+			`{"name":"ID","value":{"type":"int","value":7}},` +
+			`{"name":"Owner","value":{"type":"*spew_test.person","fields":[` +
+			`{"name":"Name","value":{"type":"string","len":3,"value":"bob"}},` +
+			`{"name":"Account","value":{"type":"*spew_test.account","fields":[` +
+			`{"name":"ID","value":{"type":"int","value":7}},` +
+			`{"name":"Owner","value":{"type":"*spew_test.person","circular":true}}]}}]}}]}`},
+	}
+
+	for i, test := range tests {
+		s := test.cs.Sjson(test.in)
+		if s != test.want+"\n" {
+			t.Errorf("SjsonOptions #%d\n got: %s want: %s", i, s, test.want)
+			continue
+		}
+
+		if !json.Valid([]byte(s)) {
+			t.Errorf("SjsonOptions #%d produced invalid JSON: %s", i, s)
+		}
+	}
+}
+
+// TestFjson ensures Fjson writes one JSON document per argument.
+func TestFjson(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := spew.Fjson(buf, 1, "a"); err != nil {
+		t.Fatalf("Fjson: %v", err)
+	}
+
+	want := `{"type":"int","value":1}` + "\n" + `{"type":"string","len":1,"value":"a"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("Fjson\n got: %s want: %s", buf.String(), want)
+	}
+	if s := spew.Sjson(1, "a"); s != want {
+		t.Errorf("Sjson\n got: %s want: %s", s, want)
+	}
+}
@@ -0,0 +1,137 @@
+package spew
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	outputTruncatedBytes     = []byte("<output truncated>")
+	outputTruncatedDumpBytes = []byte("\n<output truncated>\n")
+)
+
+const (
+	moreSuffix         = " more"
+	moreBytesSuffix    = " more bytes"
+	bytesOmittedSuffix = " bytes omitted"
+)
+
+// limitWriter passes at most n bytes to w and discards everything written to
+// it afterwards.  The marker is written once the limit is reached.
+type limitWriter struct {
+	w      io.Writer
+	n      int
+	marker []byte
+
+	// colored specifies whether the output contains color escape sequences.
+	// They do not count towards the limit, so it truncates the output at
+	// the same point regardless of colors, and are never cut in half.
+	colored bool
+
+	// full is set once the limit is reached.
+	full bool
+}
+
+// newLimitWriter returns a limitWriter passing at most n bytes to w.
+func newLimitWriter(w io.Writer, n int, colored bool, marker []byte) *limitWriter {
+	return &limitWriter{w: w, n: n, colored: colored, marker: marker}
+}
+
+// Write writes as much of p as the limit allows.  It always reports success,
+// so the callers keep going, but should check full to stop early.
+func (l *limitWriter) Write(p []byte) (n int, err error) {
+	if l.full {
+		return len(p), nil
+	}
+
+	size, cut := len(p), l.n
+	if l.colored {
+		size, cut = visibleLen(p, l.n)
+	}
+	if size <= l.n {
+		l.n -= size
+		return l.w.Write(p)
+	}
+
+	l.w.Write(p[:cut])
+	if l.colored {
+		// The color of the truncated text must not apply to the marker.
+		l.w.Write(highlightEndBytes)
+	}
+	l.w.Write(l.marker)
+	l.n = 0
+	l.full = true
+
+	return len(p), nil
+}
+
+// visibleLen returns the number of bytes of p which are not part of color
+// escape sequences, and the length of the prefix of p which holds the first
+// max of them.
+func visibleLen(p []byte, max int) (size, cut int) {
+	cut = -1
+	pos := 0
+	add := func(end int) {
+		visible := end - pos
+		if cut < 0 && size+visible >= max {
+			cut = pos + max - size
+		}
+		size += visible
+	}
+
+	for _, loc := range ansiEscapeRE.FindAllIndex(p, -1) {
+		add(loc[0])
+		pos = loc[1]
+	}
+	add(len(p))
+
+	if cut < 0 {
+		cut = len(p)
+	}
+	return size, cut
+}
+
+// limitFull returns whether the limitWriter l, which may be nil, reached its
+// limit.
+func limitFull(l *limitWriter) bool {
+	return l != nil && l.full
+}
+
+// shownElements returns how many of the n elements of an array, slice or map
+// are displayed according to the MaxElements option of c.
+func (c *ConfigState) shownElements(n int) int {
+	if c.MaxElements > 0 && n > c.MaxElements {
+		return c.MaxElements
+	}
+
+	return n
+}
+
+// truncateString returns s cut to at most max bytes without splitting a UTF-8
+// encoded character, and the number of bytes which were cut off.  A max of 0
+// means there is no limit.
+func truncateString(s string, max int) (string, int) {
+	if max <= 0 || len(s) <= max {
+		return s, 0
+	}
+
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut], len(s) - cut
+}
+
+// canceledMarker returns the marker for a dump which was stopped because its
+// context is done with err, e.g. "<canceled: deadline exceeded>".
+func canceledMarker(err error) []byte {
+	return []byte("<canceled: " + strings.TrimPrefix(err.Error(), "context ") + ">")
+}
+
+// moreMarker returns the marker for n omitted things, e.g. "... 5 more".
+func moreMarker(n int, suffix string) []byte {
+	return []byte("... " + strconv.Itoa(n) + suffix)
+}
@@ -0,0 +1,112 @@
+package spew_test
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// TestLimits ensures the MaxElements, MaxStringLen, MaxBytes and
+// MaxOutputBytes options bound Dump and Formatter output.
+func TestLimits(t *testing.T) {
+	elems := &spew.ConfigState{Indent: " ", SortKeys: true, MaxElements: 2}
+	strs := &spew.ConfigState{Indent: " ", MaxStringLen: 2}
+	hex := &spew.ConfigState{Indent: " ", MaxBytes: 32}
+	hexOdd := &spew.ConfigState{Indent: " ", MaxBytes: 8}
+	output := &spew.ConfigState{Indent: " ", MaxOutputBytes: 40}
+	short := &spew.ConfigState{MaxOutputBytes: 8}
+
+	elems.RegisterFormatter(reflect.TypeOf(money{}), func(p *spew.Printer, v reflect.Value) {
+		for i := 0; i < 3; i++ {
+			p.Elem(i)
+		}
+	})
+
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{elems.Sdump([]int{1, 2, 3, 4, 5}), "([]int) (len=5 cap=5) {\n" +
+			" (int) 1,\n" +
+			" (int) 2,\n" +
+			" ... 3 more\n" +
+			"}\n"},
+		{elems.Sdump([]int{1, 2}), "([]int) (len=2 cap=2) {\n" +
+			" (int) 1,\n" +
+			" (int) 2\n" +
+			"}\n"},
+		{elems.Sdump(map[string]int{"a": 1, "b": 2, "c": 3}), "(map[string]int) (len=3) {\n" +
+			" (string) (len=1) \"a\": (int) 1,\n" +
+			" (string) (len=1) \"b\": (int) 2,\n" +
+			" ... 1 more\n" +
+			"}\n"},
+		{elems.Sprintf("%v", [5]int{1, 2, 3, 4, 5}), "[1 2 ... 3 more]"},
+		{elems.Sprintf("%v", map[string]int{"a": 1, "b": 2, "c": 3}), "map[a:1 b:2 ... 1 more]"},
+		{elems.Sdump(money{}), "(spew_test.money) {\n" +
+			" (int) 0,\n" +
+			" (int) 1,\n" +
+			" ... 1 more\n" +
+			"}\n"},
+		{elems.Sprintf("%v", money{}), "[0 1 ... 1 more]"},
+		{strs.Sdump("héllo"), "(string) (len=6) \"h\" ... 5 more bytes\n"},
+		{strs.Sprintf("%v", []string{"abc", "de"}), "[ab ... 1 more bytes de]"},
+		{hex.Sdump(data), "([]uint8) (len=64 cap=64) {\n" +
+			" 00000000  00 01 02 03 04 05 06 07  08 09 0a 0b 0c 0d 0e 0f  |................|\n" +
+			" ... 32 bytes omitted\n" +
+			" 00000030  30 31 32 33 34 35 36 37  38 39 3a 3b 3c 3d 3e 3f  |0123456789:;<=>?|\n" +
+			"}\n"},
+		{hexOdd.Sdump(data[:25]), "([]uint8) (len=25 cap=64) {\n" +
+			" 00000000  00 01 02 03                                       |....|\n" +
+			" ... 17 bytes omitted\n" +
+			" 00000015  15 16 17 18                                       |....|\n" +
+			"}\n"},
+		{output.Sdump([]int{1, 2, 3, 4, 5}), "([]int) (len=5 cap=5) {\n" +
+			" (int) 1,\n" +
+			" (int)\n" +
+			"<output truncated>\n"},
+		{output.Sprintf("%v", strings.Repeat("x", 50)), strings.Repeat("x", 40) + "<output truncated>"},
+		{short.Sprintf("%#v", nil), "(interfa<output truncated>"},
+		{short.Sprintf("%x", "hello world"), "68656c6c<output truncated>"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("Limits #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+}
+
+// TestLimitsColor ensures MaxOutputBytes truncates colored output at the
+// same point as plain output.
+func TestLimitsColor(t *testing.T) {
+	plain := &spew.ConfigState{Indent: " ", SortKeys: true, MaxOutputBytes: 40, ColorMode: spew.ColorNever}
+	colored := &spew.ConfigState{Indent: " ", SortKeys: true, MaxOutputBytes: 40, HighlightValues: true,
+		ColorMode: spew.ColorAlways}
+	escapes := regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+	values := []interface{}{
+		[]int{1, 2, 3, 4, 5},
+		map[string]bool{"a": true, "b": false, "c": true},
+		[]string{"abc", "defghijklmnopqrstuvwxyz"},
+	}
+
+	for i, v := range values {
+		want := plain.Sdump(v)
+		if got := escapes.ReplaceAllString(colored.Sdump(v), ""); got != want {
+			t.Errorf("LimitsColor Sdump #%d\n got: %s want: %s", i, got, want)
+		}
+
+		want = plain.Sprintf("%v", v)
+		if got := escapes.ReplaceAllString(colored.Sprintf("%v", v), ""); got != want {
+			t.Errorf("LimitsColor Sprintf #%d\n got: %s want: %s", i, got, want)
+		}
+	}
+}
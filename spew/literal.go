@@ -0,0 +1,571 @@
+package spew
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bytesType is the reflect.Type of a byte slice, which is written as []byte
+// rather than []uint8.
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// literalPtr identifies the target of a pointer.  The type is needed to
+// distinguish pointers to a struct and to its first field.
+type literalPtr struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// literalState contains information about the state of a Go literal
+// operation.  The literal is written from the tree of walkRaw, so it shows
+// the same fields, elements and entries as Dump.
+type literalState struct {
+	// refs counts the references to every pointer target.  Targets which
+	// are referenced more than once are declared as helper variables.
+	refs map[literalPtr]int
+
+	// vars holds the names of the helper variables declared so far, active
+	// the ones whose literal is currently being built.
+	vars   map[literalPtr]string
+	active map[literalPtr]bool
+
+	// fixups holds the assignments of circular references, which can only
+	// be made once the referenced helper variable is declared.
+	fixups map[literalPtr][]string
+
+	// stmts holds the helper variable declarations and fixups.
+	stmts []string
+}
+
+// newLiteralState returns a literalState for writing the tree n.
+func newLiteralState(n *Node) *literalState {
+	l := &literalState{
+		refs:   make(map[literalPtr]int),
+		vars:   make(map[literalPtr]string),
+		active: make(map[literalPtr]bool),
+		fixups: make(map[literalPtr][]string),
+	}
+	l.countRefs(n)
+
+	return l
+}
+
+// valueLiteral returns the Go syntax of v, e.g. of a map key in a path.
+func valueLiteral(cs *ConfigState, v reflect.Value) string {
+	n := walkRaw(cs, v)
+	return newLiteralState(n).literal(n, 0, false, "", false)
+}
+
+// countRefs counts the references to all pointer targets reachable from n.
+// Skipped and redacted struct fields are not written, so the references they
+// hold are not counted.  Skipped fields are not part of the tree at all.
+func (l *literalState) countRefs(n *Node) {
+	if n.Redacted {
+		return
+	}
+
+	for level := 0; level <= n.Indirects; level++ {
+		v := derefValue(n.val, level)
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Type().Elem() == locationType {
+			break
+		}
+
+		key := literalPtr{v.Pointer(), v.Type()}
+		l.refs[key]++
+		if l.refs[key] > 1 {
+			// The target was counted where it was referenced first.
+			return
+		}
+	}
+
+	for _, c := range n.Children {
+		if c.Key != nil {
+			l.countRefs(c.Key)
+		}
+		l.countRefs(c)
+	}
+}
+
+// typeString returns the Go syntax of the type t.
+func typeString(t reflect.Type) string {
+	if t == bytesType {
+		return "[]byte"
+	}
+
+	return t.String()
+}
+
+// typedNil returns the nil value of the type t.  The conversion is only
+// needed if the type is not known from the context.
+func typedNil(t reflect.Type, typed bool) string {
+	if !typed {
+		return "nil"
+	}
+
+	return "(" + typeString(t) + ")(nil)"
+}
+
+// convert returns the constant lit converted to the type t, unless the
+// type is not needed or lit already has the default type t.
+func convert(t reflect.Type, lit string, typed bool) string {
+	if !typed || t.PkgPath() == "" && t.Name() == defaultTypeName(lit) {
+		return lit
+	}
+
+	return typeString(t) + "(" + lit + ")"
+}
+
+// defaultTypeName returns the name of the default type of the constant lit.
+func defaultTypeName(lit string) string {
+	switch {
+	case lit == "true" || lit == "false":
+		return "bool"
+	case strings.HasPrefix(lit, `"`):
+		return "string"
+	case strings.HasPrefix(lit, "complex("):
+		return "complex128"
+	case strings.ContainsAny(lit, ".eEIN"):
+		return "float64"
+	}
+
+	return "int"
+}
+
+// floatLiteral returns the Go syntax of the floating point value f, which is
+// expected to be 32 or 64bit.
+func floatLiteral(f float64, precision int) string {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()"
+	case math.IsInf(f, 1):
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)"
+	}
+
+	lit := strconv.FormatFloat(f, 'g', -1, precision)
+	if !strings.ContainsAny(lit, ".e") {
+		lit += ".0"
+	}
+
+	return lit
+}
+
+// locationLiteral returns the Go syntax of the location loc.
+func locationLiteral(loc *time.Location) string {
+	switch loc {
+	case time.UTC:
+		return "time.UTC"
+	case time.Local:
+		return "time.Local"
+	}
+
+	return "func() *time.Location { loc, _ := time.LoadLocation(" +
+		strconv.Quote(loc.String()) + "); return loc }()"
+}
+
+// timeLiteral returns the Go syntax of time.Time, time.Duration and
+// *time.Location values.  The returned bool is false if v does not hold one
+// of these types or its value is inaccessible.
+func timeLiteral(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case durationType:
+		return "time.Duration(" + strconv.FormatInt(v.Int(), 10) + ")", true
+	case timeType, reflect.PtrTo(locationType):
+	default:
+		return "", false
+	}
+
+	// Unexported struct fields can only be accessed by bypassing the
+	// visibility rules, see handleMethods.
+	if !v.CanInterface() {
+		if UnsafeDisabled {
+			return "", false
+		}
+
+		v = unsafeReflectValue(v)
+	}
+
+	switch val := v.Interface().(type) {
+	case *time.Location:
+		return locationLiteral(val), true
+
+	case time.Time:
+		loc := locationLiteral(val.Location())
+		if loc != "time.UTC" && loc != "time.Local" {
+			name, offset := val.Zone()
+			loc = "time.FixedZone(" + strconv.Quote(name) + ", " + strconv.Itoa(offset) + ")"
+		}
+
+		return fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, %d, %s)",
+			val.Year(), val.Month(), val.Day(), val.Hour(), val.Minute(),
+			val.Second(), val.Nanosecond(), loc), true
+	}
+
+	return "", false
+}
+
+// ptrLiteral returns the Go syntax of the non-nil pointer at the passed level
+// of the pointer chain of n.  Pointers to composite types are written as
+// &T{...}, all others are wrapped in a function literal.  path is the
+// expression reaching the pointer, see literal.
+func (l *literalState) ptrLiteral(n *Node, level int, path string) string {
+	elem := derefValue(n.val, level).Type().Elem()
+
+	switch elem.Kind() {
+	case reflect.Struct, reflect.Array:
+		if elem != timeType {
+			// Fields and elements are selected through the pointer.
+			return "&" + l.literal(n, level+1, false, path, true)
+		}
+
+	case reflect.Slice, reflect.Map:
+		if path != "" {
+			path = "(*" + path + ")"
+		}
+		return "&" + l.literal(n, level+1, false, path, true)
+	}
+
+	// The walk unpacks interfaces along the chain, so the type of their
+	// values has to be part of the literal.
+	typ := typeString(elem)
+	return "func() *" + typ + " { var v " + typ + " = " +
+		l.literal(n, level+1, elem.Kind() == reflect.Interface, "", false) + "; return &v }()"
+}
+
+// varRef returns the name of the helper variable holding the pointer at the
+// passed level of the pointer chain of n, declaring it if necessary.  typed
+// is passed on to typedNil for circular references which can not be
+// assigned.
+func (l *literalState) varRef(key literalPtr, n *Node, level int, path string, typed bool) string {
+	if name, ok := l.vars[key]; ok {
+		// The variable is still being declared, so this is a circular
+		// reference which has to be assigned once it exists.  Values
+		// without a path, e.g. fields of structs stored in maps, can not
+		// be assigned to, so the reference is replaced by a comment.
+		if l.active[key] {
+			if path == "" {
+				return typedNil(key.typ, typed) + " /* circular: " + name + " */"
+			}
+
+			l.fixups[key] = append(l.fixups[key], path+" = "+name)
+			return "nil"
+		}
+
+		return name
+	}
+
+	name := "p" + strconv.Itoa(len(l.vars)+1)
+	l.vars[key] = name
+	l.active[key] = true
+	lit := l.ptrLiteral(n, level, name)
+	delete(l.active, key)
+
+	l.stmts = append(l.stmts, name+" := "+lit)
+	l.stmts = append(l.stmts, l.fixups[key]...)
+
+	return name
+}
+
+// literal returns the Go syntax of the value at the passed level of the
+// pointer chain of n, i.e. after following level pointers.  typed specifies
+// whether the type of the value is unknown from the context, e.g. inside
+// interfaces, and therefore has to be part of the literal.
+//
+// path is the expression which reaches the value starting from the helper
+// variable currently being declared, or empty if it can not be assigned to.
+// It is used to assign circular references.  addressable specifies whether
+// the fields and elements of the value can be assigned to.
+func (l *literalState) literal(n *Node, level int, typed bool, path string, addressable bool) string {
+	if n.Invalid {
+		return "nil"
+	}
+
+	if level == 0 && n.val.Kind() == reflect.Interface {
+		if n.val.IsNil() {
+			return "nil"
+		}
+		typed, addressable = true, false
+	}
+
+	// Nil interfaces end pointer chains.
+	v := derefValue(n.val, level)
+	if v.Kind() == reflect.Interface {
+		return "nil"
+	}
+
+	if lit, ok := timeLiteral(v); ok {
+		return lit
+	}
+
+	vt := v.Type()
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return typedNil(vt, typed)
+		}
+
+		key := literalPtr{v.Pointer(), vt}
+		if l.refs[key] > 1 {
+			return l.varRef(key, n, level, path, typed)
+		}
+		if level == n.Indirects {
+			// The walk only stops following a pointer referenced once
+			// if it refers to the start of a value which is still
+			// being written, e.g. a struct whose first field holds it.
+			return typedNil(vt, typed) + " /* circular */"
+		}
+		return l.ptrLiteral(n, level, path)
+	}
+
+	// The value is the end of the pointer chain, which n describes.
+	switch n.Kind {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return convert(vt, n.Value, typed)
+
+	case reflect.Uintptr:
+		return convert(vt, "0x"+strconv.FormatUint(v.Uint(), 16), true)
+
+	case reflect.Float32, reflect.Float64:
+		return convert(vt, floatLiteral(v.Float(), vt.Bits()), typed)
+
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		bits := vt.Bits() / 2
+		lit := "complex(" + floatLiteral(real(c), bits) + ", " + floatLiteral(imag(c), bits) + ")"
+		return convert(vt, lit, typed)
+
+	case reflect.String:
+		return convert(vt, strconv.Quote(n.Value), typed)
+
+	case reflect.UnsafePointer:
+		if n.Nil {
+			return typedNil(vt, typed)
+		}
+		return "unsafe.Pointer(uintptr(0x" + strconv.FormatUint(uint64(v.Pointer()), 16) + "))"
+
+	case reflect.Chan, reflect.Func:
+		// Channels and functions can not be expressed as literals.
+		return typedNil(vt, typed)
+
+	case reflect.Slice:
+		if n.Nil {
+			return typedNil(vt, typed)
+		}
+		return l.elemsLiteral(n, path, true)
+
+	case reflect.Array:
+		if path != "" && !addressable {
+			path = ""
+		}
+		return l.elemsLiteral(n, path, addressable)
+
+	case reflect.Map:
+		if n.Nil {
+			return typedNil(vt, typed)
+		}
+		return l.mapLiteral(n, path)
+
+	case reflect.Struct:
+		if path != "" && !addressable {
+			path = ""
+		}
+		return l.structLiteral(n, path, addressable)
+	}
+
+	return typedNil(vt, typed)
+}
+
+// elemsLiteral returns the Go syntax of the array or slice n.  Byte arrays
+// and slices are written in hexadecimal.
+func (l *literalState) elemsLiteral(n *Node, path string, addressable bool) string {
+	var buf bytes.Buffer
+	buf.WriteString(typeString(n.typ))
+	buf.Write(openBraceBytes)
+
+	// Hex dumped arrays and slices, which includes the ones of C char
+	// types, have no children.
+	signed := n.typ.Elem().Kind() == reflect.Int8
+	for i, b := range n.Bytes {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if signed {
+			buf.WriteString(strconv.Itoa(int(int8(b))))
+		} else {
+			fmt.Fprintf(&buf, "0x%02x", b)
+		}
+	}
+
+	for i, c := range n.Children {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		elemPath := ""
+		if path != "" {
+			elemPath = path + "[" + strconv.Itoa(i) + "]"
+		}
+		buf.WriteString(l.literal(c, 0, false, elemPath, addressable))
+	}
+
+	buf.Write(closeBraceBytes)
+	return buf.String()
+}
+
+// mapLiteral returns the Go syntax of the non-nil map n.  The entries are
+// written in the order of the walk, which sorts them if SortKeys is set.
+func (l *literalState) mapLiteral(n *Node, path string) string {
+	var buf bytes.Buffer
+	buf.WriteString(typeString(n.typ))
+	buf.Write(openBraceBytes)
+
+	for i, c := range n.Children {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		keyLit := l.literal(c.Key, 0, false, "", false)
+		valPath := ""
+		if path != "" {
+			valPath = path + "[" + keyLit + "]"
+		}
+
+		buf.WriteString(keyLit)
+		buf.Write(colonSpaceBytes)
+		buf.WriteString(l.literal(c, 0, false, valPath, false))
+	}
+
+	buf.Write(closeBraceBytes)
+	return buf.String()
+}
+
+// structLiteral returns the Go syntax of the struct n.  Fields holding their
+// zero value are omitted and redacted ones are replaced by a comment.
+// Skipped fields are not part of the tree.
+func (l *literalState) structLiteral(n *Node, path string, addressable bool) string {
+	var buf bytes.Buffer
+	buf.WriteString(typeString(n.typ))
+	buf.Write(openBraceBytes)
+
+	hasFields, hasComments := false, false
+	for _, c := range n.Children {
+		// A comment is separated by a space only, since it is no element
+		// of the literal.
+		if c.Redacted {
+			if hasFields || hasComments {
+				buf.WriteByte(' ')
+			}
+			hasComments = true
+			buf.WriteString("/* " + c.Name + ": " + string(redactedBytes) + " */")
+			continue
+		}
+
+		if c.val.IsZero() {
+			continue
+		}
+
+		switch {
+		case hasFields:
+			buf.WriteString(", ")
+		case hasComments:
+			buf.WriteByte(' ')
+		}
+		hasFields = true
+
+		fieldPath := ""
+		if path != "" {
+			fieldPath = path + "." + c.Name
+		}
+
+		buf.WriteString(c.Name)
+		buf.Write(colonSpaceBytes)
+		buf.WriteString(l.literal(c, 0, false, fieldPath, addressable))
+	}
+
+	buf.Write(closeBraceBytes)
+	return buf.String()
+}
+
+// fliteral is a helper function to consolidate the logic from the various
+// public methods which take varying writers and config states.
+func fliteral(cs *ConfigState, w io.Writer, a ...interface{}) {
+	for _, arg := range a {
+		if arg == nil {
+			w.Write([]byte("nil"))
+			w.Write(newlineBytes)
+			continue
+		}
+
+		v := reflect.ValueOf(arg)
+		n := walkRaw(cs, v)
+		l := newLiteralState(n)
+		lit := l.literal(n, 0, true, "", false)
+
+		// Helper variables require a function literal to be declared in.
+		if len(l.stmts) > 0 {
+			var buf bytes.Buffer
+			buf.WriteString("func() " + typeString(v.Type()) + " {\n")
+			for _, stmt := range l.stmts {
+				buf.WriteString("\t" + stmt + "\n")
+			}
+			buf.WriteString("\treturn " + lit + "\n}()")
+			lit = buf.String()
+		}
+
+		w.Write([]byte(lit))
+		w.Write(newlineBytes)
+	}
+}
+
+// Fliteral writes the passed arguments to io.Writer w as Go literals.  It
+// writes exactly the same as Sliteral.
+func Fliteral(w io.Writer, a ...interface{}) {
+	fliteral(&Config, w, a...)
+}
+
+/*
+Sliteral returns the passed arguments as Go literals, one per line, which can
+be pasted into Go source code, e.g. to turn a captured value into a regression
+test:
+
+	main.T{A: 1, B: &main.U{Data: []byte{0x01, 0x02}}}
+
+Struct fields holding their zero value are omitted, as are the ones skipped by
+the spew struct tag or a FieldRule.  Redacted fields are replaced by a comment
+holding their name and <redacted>.  Values stored in interfaces are converted
+to their type, e.g. int8(5).  Pointers which are referenced more than once,
+including circular references, are declared as helper variables inside a
+function literal:
+
+	func() *main.Node {
+		p1 := &main.Node{Name: "a"}
+		p1.Next = p1
+		return p1
+	}()
+
+Circular references which can not be assigned afterwards, e.g. the ones held
+by struct values stored in maps, are written as nil followed by a comment
+naming the helper variable, e.g. "circular: p1".  Pointers to the first field
+of a struct held by that field itself are written as nil followed by the
+comment "circular".
+
+Channels and functions can not be expressed as literals and are written as
+nil.  The literals may refer to the math, time and unsafe packages and to
+unexported identifiers, which only compile inside the package defining them.
+Use gofmt to format long literals.
+
+The configuration options are controlled by an exported package global,
+spew.Config.  See ConfigState for options documentation.
+*/
+func Sliteral(a ...interface{}) string {
+	var buf bytes.Buffer
+	fliteral(&Config, &buf, a...)
+	return buf.String()
+}
@@ -0,0 +1,205 @@
+package spew_test
+
+import (
+	"go/parser"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// literalTest is used to describe a test to be performed against the
+// Sliteral method.
+type literalTest struct {
+	in   interface{}
+	want string
+}
+
+type litInner struct {
+	Data []byte
+}
+
+type litOuter struct {
+	Name  string
+	Count int8
+	Inner *litInner
+	Any   interface{}
+	Tags  map[string]int
+	At    time.Time
+	Fn    func()
+}
+
+type litNode struct {
+	Name string
+	Next *litNode
+	Kids []*litNode
+	Refs map[string]*litNode
+}
+
+// litLink and litGraph hold circular references through struct values
+// stored in maps, which can not be assigned to.
+type litLink struct {
+	To *litGraph
+}
+
+type litGraph struct {
+	Links map[string]litLink
+}
+
+// litItem is the first field of litList, so a pointer to it shares its
+// address with a pointer to the list.
+type litItem struct {
+	Self *litItem
+}
+
+type litList struct {
+	First litItem
+	Len   int
+}
+
+// TestSliteral ensures Sliteral produces Go literals.
+func TestSliteral(t *testing.T) {
+	i := 5
+	cet := time.FixedZone("CET", 3600)
+
+	shared := &litInner{Data: []byte{1}}
+	cycle := &litNode{Name: "a"}
+	cycle.Next = cycle
+	cycle.Refs = map[string]*litNode{"self": cycle}
+	child := &litNode{Name: "b", Next: cycle}
+	cycle.Kids = []*litNode{child, child}
+
+	graph := &litGraph{}
+	graph.Links = map[string]litLink{"self": {To: graph}}
+
+	list := &litList{Len: 1}
+	list.First.Self = &list.First
+
+	tests := []literalTest{
+		{nil, "nil"},
+		{5, "5"},
+		{int8(-5), "int8(-5)"},
+		{uint(5), "uint(5)"},
+		{2.0, "2.0"},
+		{float32(1.5), "float32(1.5)"},
+		{math.Inf(-1), "math.Inf(-1)"},
+		{complex(1, -2), "complex(1.0, -2.0)"},
+		{"a\"b", `"a\"b"`},
+		{true, "true"},
+		{stringer("s"), `spew_test.stringer("s")`},
+		{uintptr(0xff), "uintptr(0xff)"},
+		{&i, "func() *int { var v int = 5; return &v }()"},
+		{(*int)(nil), "(*int)(nil)"},
+		{[]int(nil), "([]int)(nil)"},
+		{[]byte{0, 0xab}, "[]byte{0x00, 0xab}"},
+		{[2]string{"a", "b"}, `[2]string{"a", "b"}`},
+		{[]interface{}{1, int64(2), nil}, "[]interface {}{1, int64(2), nil}"},
+		{map[string]bool{"b": false, "a": true}, `map[string]bool{"a": true, "b": false}`},
+		{struct{ A int }{1}, "struct { A int }{A: 1}"},
+		{90 * time.Second, "time.Duration(90000000000)"},
+		{time.Date(2021, 3, 4, 5, 6, 7, 8, cet),
+			`time.Date(2021, time.March, 4, 5, 6, 7, 8, time.FixedZone("CET", 3600))`},
+		{litOuter{
+			Name:  "a",
+			Count: 3,
+			Inner: &litInner{Data: []byte{1, 2}},
+			Any:   uint8(1),
+			Tags:  map[string]int{"x": 1},
+			At:    time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+			Fn:    func() {},
+		}, `spew_test.litOuter{Name: "a", Count: 3, Inner: &spew_test.litInner{Data: []byte{0x01, 0x02}}, ` +
+			`Any: uint8(1), Tags: map[string]int{"x": 1}, ` +
+			`At: time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC), Fn: nil}`},
+		{[]*litInner{shared, shared}, "func() []*spew_test.litInner {\n" +
+			"\tp1 := &spew_test.litInner{Data: []byte{0x01}}\n" +
+			"\treturn []*spew_test.litInner{p1, p1}\n" +
+			"}()"},
+		{cycle, "func() *spew_test.litNode {\n" +
+			"\tp2 := &spew_test.litNode{Name: \"b\", Next: nil}\n" +
+			"\tp1 := &spew_test.litNode{Name: \"a\", Next: nil, Kids: []*spew_test.litNode{p2, p2}, " +
+			"Refs: map[string]*spew_test.litNode{\"self\": nil}}\n" +
+			"\tp1.Next = p1\n" +
+			"\tp2.Next = p1\n" +
+			"\tp1.Refs[\"self\"] = p1\n" +
+			"\treturn p1\n" +
+			"}()"},
+		{graph, "func() *spew_test.litGraph {\n" +
+			"\tp1 := &spew_test.litGraph{Links: map[string]spew_test.litLink{" +
+			"\"self\": spew_test.litLink{To: nil /* circular: p1 */}}}\n" +
+			"\treturn p1\n" +
+			"}()"},
+		{[]interface{}{graph, graph}, "func() []interface {} {\n" +
+			"\tp1 := &spew_test.litGraph{Links: map[string]spew_test.litLink{" +
+			"\"self\": spew_test.litLink{To: nil /* circular: p1 */}}}\n" +
+			"\treturn []interface {}{p1, p1}\n" +
+			"}()"},
+		{list, "&spew_test.litList{First: spew_test.litItem{Self: nil /* circular */}, Len: 1}"},
+	}
+
+	cfg := spew.ConfigState{SortKeys: true}
+	for i, test := range tests {
+		s := cfg.Sliteral(test.in)
+		if s != test.want+"\n" {
+			t.Errorf("Sliteral #%d\n got: %s want: %s", i, s, test.want)
+			continue
+		}
+
+		if _, err := parser.ParseExpr(strings.TrimSuffix(s, "\n")); err != nil {
+			t.Errorf("Sliteral #%d is not a valid expression: %v\n%s", i, err, s)
+		}
+	}
+}
+
+// litCredentials has fields which are skipped or redacted.
+type litCredentials struct {
+	User     string
+	Password string `spew:"redact"`
+	Secret   string `spew:"-"`
+	Token    string
+}
+
+// litShared holds the same pointer in a shown, a skipped and a redacted
+// field.
+type litShared struct {
+	A *litInner
+	B *litInner `spew:"-"`
+	C *litInner `spew:"redact"`
+}
+
+// TestSliteralFields ensures skipped and redacted struct fields never show
+// up in the literals.
+func TestSliteralFields(t *testing.T) {
+	inner := &litInner{Data: []byte{1}}
+
+	tests := []literalTest{
+		{litCredentials{User: "u", Password: "pw", Secret: "s", Token: "t"},
+			`spew_test.litCredentials{User: "u" /* Password: <redacted> */, Token: "t"}`},
+		{litCredentials{Token: "t"},
+			`spew_test.litCredentials{/* Password: <redacted> */ Token: "t"}`},
+		{[]litCredentials{{Secret: "s", Token: "t"}},
+			`[]spew_test.litCredentials{spew_test.litCredentials{/* Password: <redacted> */ /* Token: <redacted> */}}`},
+		{litShared{A: inner, B: inner, C: inner},
+			`spew_test.litShared{A: &spew_test.litInner{Data: []byte{0x01}} /* C: <redacted> */}`},
+		{[]litShared{{A: inner, B: inner}, {A: inner}},
+			`[]spew_test.litShared{spew_test.litShared{/* A: <redacted> */ /* C: <redacted> */}, ` +
+				`spew_test.litShared{A: &spew_test.litInner{Data: []byte{0x01}} /* C: <redacted> */}}`},
+	}
+
+	cfg := spew.ConfigState{FieldRules: []spew.FieldRule{
+		{Path: "[0].Token", Action: spew.FieldRedact},
+		{Path: "[0].A", Action: spew.FieldRedact},
+	}}
+	for i, test := range tests {
+		s := cfg.Sliteral(test.in)
+		if s != test.want+"\n" {
+			t.Errorf("SliteralFields #%d\n got: %s want: %s", i, s, test.want)
+			continue
+		}
+
+		if _, err := parser.ParseExpr(strings.TrimSuffix(s, "\n")); err != nil {
+			t.Errorf("SliteralFields #%d is not a valid expression: %v\n%s", i, err, s)
+		}
+	}
+}
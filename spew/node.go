@@ -0,0 +1,163 @@
+package spew
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Node is a generic representation of a single value displayed by Dump.  It
+// holds the same information as the output of Dump, so tools can inspect and
+// re-render values without access to their Go types.  Nodes are produced by
+// Walk and by ParseDump from captured output.
+//
+// Pointers do not have nodes of their own.  The node of a pointer holds the
+// pointer type, including all indirections, and the chain of addresses, while
+// all other members describe the value at the end of the chain.
+type Node struct {
+	// Type is the type of the value, e.g. "map[string]int" or "*main.T".
+	Type string
+
+	// Kind is the kind of the value, which is the kind of the value pointed
+	// to for pointers.  It is reflect.Ptr if the chain of pointers ends at
+	// a nil pointer or one to a value which is already shown.  Parsed nodes
+	// have reflect.Invalid if the kind can not be told, e.g. for a named
+	// type which is displayed by its Stringer.
+	Kind reflect.Kind
+
+	// Indirects is the number of pointers followed to reach the value.
+	// Parsed nodes of pointers to omitted values count all indirections of
+	// the type but the last one as followed.
+	Indirects int
+
+	// Name is the name of the struct field holding the value, if any.
+	Name string
+
+	// Key is the key of the map entry holding the value, if any.
+	Key *Node
+
+	// Addrs is the chain of pointer addresses as displayed, e.g.
+	// "0xc000012345", or "&1" if PointerIDs is set.  Walk records them
+	// regardless of DisablePointerAddresses.
+	Addrs []string
+
+	// Len and Cap are the length and capacity of the value, if displayed.
+	Len int
+	Cap int
+
+	// Value is the text of a value which is not displayed as a block, such
+	// as a number, an address or the output of a Stringer.  Strings are
+	// unquoted.
+	Value string
+
+	// Method is the result of the Error or String method of the value.
+	// Panic is the value the method panicked with, if any.  Parsed nodes
+	// hold the result in Value instead.
+	Method string
+	Panic  string
+
+	// Bytes is the contents of a hex dumped array or slice.
+	Bytes []byte
+
+	// Children are the elements of an array or slice, the values of the
+	// entries of a map or the fields of a struct.
+	Children []*Node
+
+	// More is the number of elements, or bytes of strings and hex dumps,
+	// which are omitted due to MaxElements, MaxStringLen or MaxBytes.
+	More int
+
+	// Nil specifies whether the value is nil.
+	Nil bool
+
+	// Invalid specifies whether the value is an invalid reflect.Value.
+	Invalid bool
+
+	// Circular specifies whether the value is omitted because it is part
+	// of a circular reference and already shown.
+	Circular bool
+
+	// Ref is the pointer ID, e.g. "&1", or the path of the value pointed
+	// to if it is omitted because it is already shown, see PointerIDs and
+	// DetectSharedPointers.
+	Ref string
+
+	// MaxDepth specifies whether the children are omitted because the
+	// maximum depth is reached.
+	MaxDepth bool
+
+	// Redacted specifies whether the value of a struct field is redacted.
+	Redacted bool
+
+	// typ is the type of the value, or of the value pointed to.
+	typ reflect.Type
+
+	// v is the value whose children are walked lazily, see
+	// walkState.children.
+	v reflect.Value
+
+	// val is the value n was created from, before unpacking the interface
+	// holding it and following pointers.  It is kept for redacted struct
+	// fields too, so Diff can tell whether they differ.
+	val reflect.Value
+
+	// iface specifies whether the value is stored in an interface.
+	iface bool
+
+	// time specifies whether Value is the human readable form of a time
+	// value.
+	time bool
+
+	// call is the result of the Error or String method, if called.
+	call *methodResult
+
+	// custom renders the value, see RegisterFormatter and SpewDumper.
+	custom FormatterFunc
+
+	// err is the error of the context of the walk, if it was done while a
+	// method was called.
+	err error
+
+	// expr is the Go expression the value is accessed by, if it is
+	// annotated, see AnnotatePaths.  base is the expression of the value
+	// reached by following the pointers and unpacking the interfaces.
+	expr string
+	base goExpr
+}
+
+// basicKinds maps the names of the predeclared types to their kinds.
+var basicKinds = func() map[string]reflect.Kind {
+	kinds := make(map[string]reflect.Kind)
+	for k := reflect.Bool; k <= reflect.Complex128; k++ {
+		kinds[k.String()] = k
+	}
+	kinds[reflect.String.String()] = reflect.String
+	return kinds
+}()
+
+// kindOfType returns the kind of the type named t, as printed by
+// reflect.Type.String, or reflect.Invalid if it can not be told from the name
+// alone.
+func kindOfType(t string) reflect.Kind {
+	switch {
+	case strings.HasPrefix(t, "*"):
+		return reflect.Ptr
+	case strings.HasPrefix(t, "[]"):
+		return reflect.Slice
+	case strings.HasPrefix(t, "["):
+		return reflect.Array
+	case strings.HasPrefix(t, "map["):
+		return reflect.Map
+	case strings.HasPrefix(t, "chan"), strings.HasPrefix(t, "<-chan"):
+		return reflect.Chan
+	case strings.HasPrefix(t, "func("):
+		return reflect.Func
+	case strings.HasPrefix(t, "struct {"):
+		return reflect.Struct
+	case strings.HasPrefix(t, "interface {"):
+		return reflect.Interface
+	case t == "unsafe.Pointer":
+		return reflect.UnsafePointer
+	}
+
+	return basicKinds[t]
+}
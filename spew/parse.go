@@ -0,0 +1,510 @@
+package spew
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// addrChainRE matches the chain of addresses displayed for a pointer.
+	addrChainRE = regexp.MustCompile(`^(0x[0-9a-f]+|&[0-9]+)(->(0x[0-9a-f]+|&[0-9]+))*$`)
+
+	// fieldNameRE matches the name of a struct field in front of its value.
+	fieldNameRE = regexp.MustCompile(`^[\pL_][\pL\pN_]*: `)
+
+	// hexLineRE matches the start of a line of a hex dump.
+	hexLineRE = regexp.MustCompile(`^[0-9a-fA-F]+(\s|$)`)
+)
+
+// ParseDump parses the output of Dump, which may be colorized, back into one
+// Node for each dumped value.  This allows to inspect dumps captured in logs.
+//
+// Output of Dump with an Indent which is not whitespace, of Stringer and error
+// methods which span multiple lines, output annotated by AnnotatePaths and
+// truncated output can not be parsed.
+// The kinds of named types are derived from the way their values are
+// displayed, as far as possible.
+func ParseDump(r io.Reader) ([]*Node, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dumpParser{s: ansiEscapeRE.ReplaceAllString(string(b), "")}
+
+	var nodes []*Node
+	for {
+		p.pos += len(p.rest()) - len(strings.TrimLeft(p.rest(), " \t\n"))
+		if p.pos == len(p.s) {
+			return nodes, nil
+		}
+
+		n, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume("\n") {
+			return nil, p.errorf("unexpected %q after value", p.line())
+		}
+		nodes = append(nodes, n)
+	}
+}
+
+// dumpParser contains the state of a ParseDump operation.
+type dumpParser struct {
+	s   string
+	pos int
+
+	// closers is the number of parentheses of pointers which close on the
+	// current line.
+	closers int
+}
+
+// errorf returns an error of ParseDump for the current line.
+func (p *dumpParser) errorf(format string, a ...interface{}) error {
+	lineNo := strings.Count(p.s[:p.pos], "\n") + 1
+	return fmt.Errorf("spew: dump line %d: %s", lineNo, fmt.Sprintf(format, a...))
+}
+
+// rest returns the input which is not parsed yet.
+func (p *dumpParser) rest() string {
+	return p.s[p.pos:]
+}
+
+// line returns the rest of the current line.
+func (p *dumpParser) line() string {
+	rest := p.rest()
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
+
+// consume skips prefix and returns true if the input continues with it.
+func (p *dumpParser) consume(prefix string) bool {
+	if !strings.HasPrefix(p.rest(), prefix) {
+		return false
+	}
+
+	p.pos += len(prefix)
+	return true
+}
+
+// skipIndent skips the indentation of the current line.
+func (p *dumpParser) skipIndent() {
+	rest := p.rest()
+	p.pos += len(rest) - len(strings.TrimLeft(rest, " \t"))
+}
+
+// enclosed returns the text up to the parenthesis closing the one in front of
+// the current position and skips past it.  Nested parentheses and quoted
+// strings, e.g. in struct tags, are skipped.
+func (p *dumpParser) enclosed() (string, error) {
+	start := p.pos
+	depth := 1
+	for i := start; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '"':
+			q, ok := quotedPrefix(p.s[i:])
+			if !ok {
+				return "", p.errorf("unterminated quoted string")
+			}
+			i += len(q) - 1
+
+		case '(':
+			depth++
+
+		case ')':
+			depth--
+			if depth == 0 {
+				p.pos = i + 1
+				return p.s[start:i], nil
+			}
+
+		case '\n':
+			return "", p.errorf("unterminated parenthesis")
+		}
+	}
+
+	return "", p.errorf("unterminated parenthesis")
+}
+
+// value parses a value including its type.  If key is set, the value may be
+// the key of a map entry, which is followed by ": ".
+func (p *dumpParser) value(key bool) (*Node, error) {
+	if p.consume(string(invalidAngleBytes)) {
+		return &Node{Invalid: true}, nil
+	}
+
+	if !p.consume("(") {
+		return nil, p.errorf("expected type, got %q", p.line())
+	}
+	typ, err := p.enclosed()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{Type: typ, Kind: kindOfType(typ)}
+	if n.Kind == reflect.Ptr && p.consume("(") {
+		elem := strings.TrimLeft(typ, "*")
+		n.Kind, n.Indirects = kindOfType(elem), len(typ)-len(elem)
+		return n, p.pointer(n, key)
+	}
+
+	if !p.consume(" ") {
+		return nil, p.errorf("expected value after type %s", typ)
+	}
+
+	return n, p.body(n, key)
+}
+
+// pointer parses the addresses and the value pointed to of a pointer, whose
+// type and opening parenthesis are already parsed.
+func (p *dumpParser) pointer(n *Node, key bool) error {
+	// The addresses are omitted if DisablePointerAddresses is set, so the
+	// value pointed to may also be an address.
+	start := p.pos
+	addrs, err := p.enclosed()
+	if err == nil && addrChainRE.MatchString(addrs) && p.consume("(") {
+		n.Addrs = strings.Split(addrs, string(pointerChainBytes))
+	} else {
+		p.pos = start
+	}
+
+	// The chain of pointers ends at the last one if the value pointed to is
+	// omitted.
+	omitted := func() {
+		n.Kind = reflect.Ptr
+		n.Indirects--
+	}
+
+	switch {
+	case p.consume(string(nilAngleBytes) + ")"):
+		omitted()
+		n.Nil = true
+		return nil
+
+	case p.consume(string(circularBytes) + ")"):
+		omitted()
+		n.Circular = true
+		return nil
+
+	case p.consume(string(pointerRefBytes)):
+		omitted()
+		ref, err := p.enclosed()
+		n.Ref = ref
+		return err
+
+	case p.consume(string(sameAsBytes)):
+		omitted()
+		i := strings.Index(p.line(), ">)")
+		if i < 0 {
+			return p.errorf("unterminated shared pointer path")
+		}
+		n.Ref = p.rest()[:i]
+		p.pos += i + len(">)")
+		return nil
+	}
+
+	p.closers++
+	err = p.body(n, key)
+	p.closers--
+	if err != nil {
+		return err
+	}
+
+	if !p.consume(")") {
+		return p.errorf("expected closing parenthesis of pointer")
+	}
+	return nil
+}
+
+// body parses a value following its type.
+func (p *dumpParser) body(n *Node, key bool) error {
+	if strings.HasPrefix(p.rest(), "(len=") || strings.HasPrefix(p.rest(), "(cap=") {
+		p.pos++
+		if err := p.lenCap(n); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case p.consume(string(openBraceNewlineBytes)):
+		return p.block(n)
+
+	case strings.HasPrefix(p.rest(), `"`):
+		return p.str(n)
+
+	case p.consume(string(nilAngleBytes)):
+		n.Nil = true
+		return nil
+	}
+
+	return p.scalar(n, key)
+}
+
+// lenCap parses the length and capacity of a value.
+func (p *dumpParser) lenCap(n *Node) error {
+	text, err := p.enclosed()
+	if err != nil {
+		return err
+	}
+
+	for _, field := range strings.Fields(text) {
+		var dst *int
+		switch {
+		case strings.HasPrefix(field, string(lenEqualsBytes)):
+			dst = &n.Len
+		case strings.HasPrefix(field, string(capEqualsBytes)):
+			dst = &n.Cap
+		default:
+			return p.errorf("invalid length or capacity %q", field)
+		}
+
+		v, err := strconv.Atoi(field[len("len="):])
+		if err != nil {
+			return p.errorf("invalid length or capacity %q", field)
+		}
+		*dst = v
+	}
+
+	if !p.consume(" ") {
+		return p.errorf("expected value after length and capacity")
+	}
+	return nil
+}
+
+// str parses a quoted string and the marker of its omitted bytes, if any.
+func (p *dumpParser) str(n *Node) error {
+	q, ok := quotedPrefix(p.rest())
+	if !ok {
+		return p.errorf("unterminated quoted string")
+	}
+
+	s, err := strconv.Unquote(q)
+	if err != nil {
+		return p.errorf("invalid quoted string %s", q)
+	}
+	p.pos += len(q)
+
+	n.Value = s
+	if n.Kind == reflect.Invalid {
+		n.Kind = reflect.String
+	}
+
+	if strings.HasPrefix(p.rest(), " ... ") {
+		p.pos++
+		i := strings.Index(p.line(), moreBytesSuffix)
+		if i < 0 {
+			return p.errorf("invalid marker of omitted bytes")
+		}
+		more, ok := parseMore(p.rest()[:i+len(moreBytesSuffix)], moreBytesSuffix)
+		if !ok {
+			return p.errorf("invalid marker of omitted bytes")
+		}
+		n.More = more
+		p.pos += i + len(moreBytesSuffix)
+	}
+
+	return nil
+}
+
+// scalar parses any other value, which extends to the end of the line, the
+// separator of a map entry if key is set, or the parentheses closing the
+// pointers it is part of.
+func (p *dumpParser) scalar(n *Node, key bool) error {
+	text := p.line()
+	sep := -1
+	if key {
+		sep = strings.Index(text, ": (")
+		if i := strings.Index(text, ": <"); i >= 0 && (sep < 0 || i < sep) {
+			sep = i
+		}
+	}
+	if sep >= 0 {
+		text = text[:sep]
+	} else {
+		text = strings.TrimSuffix(text, ",")
+	}
+
+	for i := 0; i < p.closers; i++ {
+		if !strings.HasSuffix(text, ")") {
+			return p.errorf("expected closing parenthesis of pointer")
+		}
+		text = text[:len(text)-1]
+	}
+
+	n.Value = text
+	p.pos += len(text)
+	return nil
+}
+
+// block parses the elements, entries or fields of a value following the
+// opening brace up to and including the closing brace.
+func (p *dumpParser) block(n *Node) error {
+	closers := p.closers
+	p.closers = 0
+	defer func() { p.closers = closers }()
+
+	for {
+		p.skipIndent()
+
+		switch line := p.line(); {
+		case p.consume(string(closeBraceBytes)):
+			p.inferKind(n)
+			return nil
+
+		case line == string(maxDepthBytes):
+			n.MaxDepth = true
+			p.pos += len(line) + 1
+			continue
+
+		case strings.HasPrefix(line, "... "):
+			more, ok := parseMore(line, moreSuffix)
+			if !ok {
+				return p.errorf("invalid marker of omitted elements")
+			}
+			n.More = more
+			p.pos += len(line) + 1
+			continue
+
+		case hexLineRE.MatchString(line):
+			if err := p.hexDump(n); err != nil {
+				return err
+			}
+			continue
+		}
+
+		child, err := p.child()
+		if err != nil {
+			return err
+		}
+		n.Children = append(n.Children, child)
+
+		if !p.consume(string(commaNewlineBytes)) && !p.consume(string(newlineBytes)) {
+			return p.errorf("unexpected %q after value", p.line())
+		}
+	}
+}
+
+// child parses an element, map entry or struct field of a block.
+func (p *dumpParser) child() (*Node, error) {
+	if m := fieldNameRE.FindString(p.rest()); m != "" {
+		p.pos += len(m)
+		name := m[:len(m)-len(colonSpaceBytes)]
+		if p.consume(string(redactedBytes)) {
+			return &Node{Name: name, Redacted: true}, nil
+		}
+
+		n, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		n.Name = name
+		return n, nil
+	}
+
+	n, err := p.value(true)
+	if err != nil || !p.consume(string(colonSpaceBytes)) {
+		return n, err
+	}
+
+	v, err := p.value(false)
+	if err != nil {
+		return nil, err
+	}
+	v.Key = n
+	return v, nil
+}
+
+// hexDump parses the hex dumped contents of a block, which may be split by a
+// marker of omitted bytes.
+func (p *dumpParser) hexDump(n *Node) error {
+	var section strings.Builder
+	start := p.pos
+	flush := func() error {
+		b, err := ParseHexDump(strings.NewReader(section.String()))
+		if err != nil {
+			// Report the error relative to the start of the section.
+			p.pos = start
+			return p.errorf("%s", strings.TrimPrefix(err.Error(), "spew: "))
+		}
+		n.Bytes = append(n.Bytes, b...)
+		section.Reset()
+		return nil
+	}
+
+	if n.Bytes == nil {
+		n.Bytes = []byte{}
+	}
+
+	for {
+		p.skipIndent()
+		line := p.line()
+		if line == "" || strings.HasPrefix(line, string(closeBraceBytes)) {
+			return flush()
+		}
+
+		if more, ok := parseMore(line, bytesOmittedSuffix); ok {
+			if err := flush(); err != nil {
+				return err
+			}
+			n.More = more
+			start = p.pos + len(line) + 1
+		} else {
+			section.WriteString(line)
+			section.WriteByte('\n')
+		}
+		p.pos += len(line)
+		p.consume("\n")
+	}
+}
+
+// inferKind sets the kind of the named type of n, which is displayed as a
+// block, if it is unknown.
+func (p *dumpParser) inferKind(n *Node) {
+	if n.Kind != reflect.Invalid || len(n.Children) == 0 {
+		return
+	}
+
+	switch {
+	case n.Children[0].Name != "" || n.Children[0].Redacted:
+		n.Kind = reflect.Struct
+	case n.Children[0].Key != nil:
+		n.Kind = reflect.Map
+	}
+}
+
+// parseMore parses a marker of n omitted things created by moreMarker.
+func parseMore(s, suffix string) (n int, ok bool) {
+	if !strings.HasPrefix(s, "... ") || !strings.HasSuffix(s, suffix) {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(s[len("... ") : len(s)-len(suffix)])
+	return n, err == nil
+}
+
+// quotedPrefix returns the double quoted string at the start of s.
+func quotedPrefix(s string) (string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", false
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], true
+		case '\n':
+			return "", false
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,158 @@
+package spew_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// parseTester is dumped and parsed back by TestParseDump.
+type parseTester struct {
+	Name  string
+	Count *int
+	Tags  map[string]bool
+	Data  []byte
+	Any   interface{}
+	Next  *parseTester
+	Empty []int
+	Weird string
+}
+
+// TestParseDump ensures the output of Dump is parsed back into the values it
+// describes, with and without colors.
+func TestParseDump(t *testing.T) {
+	count := 3
+	v := &parseTester{
+		Name:  "first",
+		Count: &count,
+		Tags:  map[string]bool{"x: (y)": true},
+		Data:  []byte("0123456789abcdefXYZ"),
+		Any:   int8(-1),
+		Weird: "),\n",
+	}
+	v.Next = v
+
+	want := []*spew.Node{{
+		Type: "*spew_test.parseTester", Kind: reflect.Struct, Indirects: 1,
+		Children: []*spew.Node{
+			{Name: "Name", Type: "string", Kind: reflect.String, Len: 5, Value: "first"},
+			{Name: "Count", Type: "*int", Kind: reflect.Int, Indirects: 1, Value: "3"},
+			{Name: "Tags", Type: "map[string]bool", Kind: reflect.Map, Len: 1, Children: []*spew.Node{
+				{Key: &spew.Node{Type: "string", Kind: reflect.String, Len: 6, Value: "x: (y)"},
+					Type: "bool", Kind: reflect.Bool, Value: "true"},
+			}},
+			{Name: "Data", Type: "[]uint8", Kind: reflect.Slice, Len: 19, Cap: 19,
+				Bytes: []byte("0123456789abcdefXYZ")},
+			{Name: "Any", Type: "int8", Kind: reflect.Int8, Value: "-1"},
+			{Name: "Next", Type: "*spew_test.parseTester", Kind: reflect.Ptr, Circular: true},
+			{Name: "Empty", Type: "[]int", Kind: reflect.Slice, Nil: true},
+			{Name: "Weird", Type: "string", Kind: reflect.String, Len: 3, Value: "),\n"},
+		},
+	}, {
+		Type: "interface {}", Kind: reflect.Interface, Nil: true,
+	}, {
+		Type: "[2]uintptr", Kind: reflect.Array, Len: 2, Cap: 2, Children: []*spew.Node{
+			{Type: "uintptr", Kind: reflect.Uintptr, Value: "0x1"},
+			{Type: "uintptr", Kind: reflect.Uintptr, Value: "0xff"},
+		},
+	}}
+
+	plain := &spew.ConfigState{Indent: " ", DisablePointerAddresses: true}
+	colored := &spew.ConfigState{Indent: "\t", DisablePointerAddresses: true,
+		HighlightValues: true, HighlightHex: true, ColorMode: spew.ColorAlways}
+
+	for _, cs := range []*spew.ConfigState{plain, colored} {
+		dump := cs.Sdump(v, nil, [2]uintptr{1, 255})
+		got, err := spew.ParseDump(strings.NewReader(dump))
+		if err != nil {
+			t.Errorf("ParseDump: unexpected error %v for\n%s", err, dump)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseDump\n got: %s want: %s", spew.Sdump(got), spew.Sdump(want))
+		}
+	}
+}
+
+// TestParseDumpMarkers ensures the addresses, references and markers of
+// omitted values are parsed.
+func TestParseDumpMarkers(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *spew.Node
+	}{
+		{"(**int)(0xc000010000->0xc000010008)(5)\n",
+			&spew.Node{Type: "**int", Kind: reflect.Int, Indirects: 2,
+				Addrs: []string{"0xc000010000", "0xc000010008"}, Value: "5"}},
+		{"(*uintptr)(0x10)\n",
+			&spew.Node{Type: "*uintptr", Kind: reflect.Uintptr, Indirects: 1, Value: "0x10"}},
+		{"(*main.T)(-> &2)\n",
+			&spew.Node{Type: "*main.T", Kind: reflect.Ptr, Ref: "&2"}},
+		{"(*main.T)(&1)(<already shown>)\n",
+			&spew.Node{Type: "*main.T", Kind: reflect.Ptr, Addrs: []string{"&1"}, Circular: true}},
+		{"(main.T) {\n S: (*int)(<same as .A>)\n}\n",
+			&spew.Node{Type: "main.T", Kind: reflect.Struct, Children: []*spew.Node{
+				{Name: "S", Type: "*int", Kind: reflect.Ptr, Ref: ".A"},
+			}}},
+		{"(main.T) {\n Secret: <redacted>,\n Inner: (main.U) {\n  <max depth reached>\n }\n}\n",
+			&spew.Node{Type: "main.T", Kind: reflect.Struct, Children: []*spew.Node{
+				{Name: "Secret", Redacted: true},
+				{Name: "Inner", Type: "main.U", MaxDepth: true},
+			}}},
+		{"(main.Level) warning\n",
+			&spew.Node{Type: "main.Level", Value: "warning"}},
+		{"(main.IDs) (len=2) {\n (*int)(0x1)(1): (main.Name) \"a\",\n ... 1 more\n}\n",
+			&spew.Node{Type: "main.IDs", Kind: reflect.Map, Len: 2, More: 1, Children: []*spew.Node{
+				{Key: &spew.Node{Type: "*int", Kind: reflect.Int, Indirects: 1, Addrs: []string{"0x1"}, Value: "1"},
+					Type: "main.Name", Kind: reflect.String, Value: "a"},
+			}}},
+		{"([]string) (len=1 cap=1) {\n (string) (len=5) \"ab\" ... 3 more bytes\n}\n",
+			&spew.Node{Type: "[]string", Kind: reflect.Slice, Len: 1, Cap: 1, Children: []*spew.Node{
+				{Type: "string", Kind: reflect.String, Len: 5, Value: "ab", More: 3},
+			}}},
+		{"([]uint8) (len=40 cap=40) {\n" +
+			" 00000000  61 62 63 64 65 66 67 68                           |abcdefgh|\n" +
+			" ... 28 bytes omitted\n" +
+			" 00000024  77 78 79 7a                                       |wxyz|\n" +
+			"}\n",
+			&spew.Node{Type: "[]uint8", Kind: reflect.Slice, Len: 40, Cap: 40, More: 28,
+				Bytes: []byte("abcdefghwxyz")}},
+		{"<invalid>\n", &spew.Node{Invalid: true}},
+	}
+
+	for i, test := range tests {
+		got, err := spew.ParseDump(strings.NewReader(test.in))
+		if err != nil {
+			t.Errorf("ParseDumpMarkers #%d: unexpected error %v", i, err)
+			continue
+		}
+		if len(got) != 1 || !reflect.DeepEqual(got[0], test.want) {
+			t.Errorf("ParseDumpMarkers #%d\n got: %s want: %s", i, spew.Sdump(got), spew.Sdump(test.want))
+		}
+	}
+}
+
+// TestParseDumpErrors ensures malformed dumps are rejected with the number of
+// the offending line.
+func TestParseDumpErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"5\n", "spew: dump line 1: expected type, got \"5\""},
+		{"(int\n", "spew: dump line 1: unterminated parenthesis"},
+		{"(main.T) {\n A: (int) 1\n", "spew: dump line 3: expected type, got \"\""},
+		{"([]uint8) (len=1 cap=1) {\n 00000000  zz\n}\n",
+			"spew: dump line 2: hex dump line 1: invalid byte \"zz\""},
+		{"(string) \"abc\n", "spew: dump line 1: unterminated quoted string"},
+	}
+
+	for i, test := range tests {
+		_, err := spew.ParseDump(strings.NewReader(test.in))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseDumpErrors #%d\n got: %v want: %s", i, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package spew
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Paths describe how a value is reached from the dumped root value, e.g.
+// .Users[0].Tags["admin"].  They consist of struct field names, slice and
+// array indices and map keys.
+
+// fieldPath returns the path of the struct field name of the value at path.
+func fieldPath(path, name string) string {
+	return path + "." + name
+}
+
+// indexPath returns the path of element i of the array or slice at path.
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// keyPath returns the path of the entry with the passed key of the map at
+// path.  The key is written as a Go literal, except for non-nil pointers and
+// channels, which are written as their type and address, e.g. (*int)(0xc0).
+func keyPath(cs *ConfigState, path string, key reflect.Value) string {
+	return path + "[" + keyLiteral(cs, key) + "]"
+}
+
+// keyLiteral returns the Go syntax of the map key key.  Pointers and channels
+// are identified by their address rather than the value they refer to, so
+// they are written as a conversion of the address.
+func keyLiteral(cs *ConfigState, key reflect.Value) string {
+	elem := key
+	if elem.Kind() == reflect.Interface && !elem.IsNil() {
+		elem = elem.Elem()
+	}
+
+	switch elem.Kind() {
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		if !elem.IsNil() {
+			return "(" + typeString(elem.Type()) + ")(0x" +
+				strconv.FormatUint(uint64(elem.Pointer()), 16) + ")"
+		}
+	}
+
+	return valueLiteral(cs, key)
+}
+
+// matchPattern reports whether s matches pattern, in which '*' matches any
+// sequence of characters and all other characters match themselves.
+func matchPattern(pattern, s string) bool {
+	px, sx := 0, 0
+
+	// position to continue at if the current attempt fails, i.e. the
+	// last '*' consumes one more character
+	starPx, starSx := -1, -1
+
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; {
+			case c == '*':
+				starPx, starSx = px, sx+1
+				px++
+				continue
+			case sx < len(s) && s[sx] == c:
+				px++
+				sx++
+				continue
+			}
+		}
+
+		if starPx >= 0 && starSx <= len(s) {
+			px, sx = starPx+1, starSx
+			starSx++
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// plainState is a minimal fmt.State without any flags which collects the
+// output of a formatState.
+type plainState struct {
+	bytes.Buffer
+}
+
+func (s *plainState) Width() (int, bool)     { return 0, false }
+func (s *plainState) Precision() (int, bool) { return 0, false }
+func (s *plainState) Flag(c int) bool        { return false }
+
+// plainString renders v in the compact form of the Formatter without type
+// information and colors.
+func plainString(cs *ConfigState, v reflect.Value) string {
+	state := new(plainState)
+	f := formatState{fs: state, cs: cs, ignoreNextType: true}
+	f.format(v)
+
+	return state.String()
+}
+
+// goExpr is the Go expression a value is accessed by, see AnnotatePaths.
+// The pointers dereferenced last are counted separately, as field
+// selectors and array indices dereference a single pointer automatically.
+// The zero value is an unknown expression, which all expressions derived
+// from are unknown too.
+type goExpr struct {
+	expr   string
+	derefs int
+}
+
+// String returns the Go syntax of the expression.
+func (e goExpr) String() string {
+	if e.derefs == 0 {
+		return e.expr
+	}
+
+	return "(" + strings.Repeat("*", e.derefs) + e.expr + ")"
+}
+
+// deref returns the expression of the value the pointer e points to.
+func (e goExpr) deref() goExpr {
+	if e.expr != "" {
+		e.derefs++
+	}
+	return e
+}
+
+// assert returns the expression of the value of type t stored in the
+// interface e.
+func (e goExpr) assert(t reflect.Type) goExpr {
+	if e.expr == "" {
+		return e
+	}
+	return goExpr{expr: e.String() + ".(" + typeString(t) + ")"}
+}
+
+// selector returns e followed by sel, which is a field selector or index.
+// auto specifies whether sel dereferences a single pointer automatically.
+func (e goExpr) selector(sel string, auto bool) goExpr {
+	if e.expr == "" {
+		return e
+	}
+	if auto && e.derefs == 1 {
+		return goExpr{expr: e.expr + sel}
+	}
+	return goExpr{expr: e.String() + sel}
+}
+
+// field returns the expression of the struct field name of e.
+func (e goExpr) field(name string) goExpr {
+	return e.selector("."+name, true)
+}
+
+// index returns the expression of element i of the array or slice e.
+func (e goExpr) index(kind reflect.Kind, i int) goExpr {
+	return e.selector("["+strconv.Itoa(i)+"]", kind == reflect.Array)
+}
+
+// key returns the expression of the entry with the passed key of the map
+// e.  The key is written as a Go literal.
+func (e goExpr) key(cs *ConfigState, key reflect.Value) goExpr {
+	if e.expr == "" {
+		return e
+	}
+	lit := valueLiteral(cs, key)
+	return e.selector("["+lit+"]", false)
+}
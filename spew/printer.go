@@ -0,0 +1,234 @@
+package spew
+
+import (
+	"reflect"
+)
+
+// FormatterFunc renders a value of the type it is registered for, see
+// RegisterFormatter.
+type FormatterFunc func(p *Printer, v reflect.Value)
+
+// SpewDumper is implemented by types which control their own Dump style
+// output.  SpewDump writes the value through p like a formatter registered
+// with RegisterFormatter does.  It is also used for the custom Formatter
+// unless the type implements SpewFormatter.
+//
+// Like the error and Stringer interfaces, SpewDumper and SpewFormatter are
+// ignored if DisableMethods is set, and implementations with a pointer
+// receiver are subject to DisablePointerMethods.
+type SpewDumper interface {
+	SpewDump(p *Printer)
+}
+
+// SpewFormatter is implemented by types which control their own output of the
+// custom Formatter, which is more compact than the Dump style output.
+type SpewFormatter interface {
+	SpewFormat(p *Printer)
+}
+
+// Printer is handed to custom formatters and the methods of SpewDumper and
+// SpewFormatter implementations to render a value.  It writes to the
+// output of the Dump or Formatter invocation the value is part of, so nested
+// values are indented, colorized and checked for circular references and the
+// maximum depth exactly like the values spew renders itself.
+//
+// A custom formatter either writes a single value, using Write, Text or
+// Value, or a block of fields or elements, using Field and Elem.  The first
+// Field or Elem call opens the block, which is closed once the formatter
+// returns.
+type Printer struct {
+	w *walkState
+
+	// n is the node of the value rendered.
+	n *Node
+
+	// emit passes the parts of the value to the renderer.  It returns
+	// false once the output is not written any further.
+	emit    func(part) bool
+	stopped bool
+
+	// opened specifies whether a block was opened by Field or Elem.
+	opened bool
+
+	// elems is the number of elements passed to Elem, of which the ones
+	// beyond MaxElements are counted in the More member of n.
+	elems int
+
+	// maxDepth specifies whether the contents of the block are omitted
+	// because the maximum depth is reached.
+	maxDepth bool
+}
+
+// Write writes b without any formatting.  It satisfies the io.Writer
+// interface, so fmt.Fprintf can be used with a Printer.
+func (p *Printer) Write(b []byte) (n int, err error) {
+	p.send(part{kind: partText, text: string(b)})
+	return len(b), nil
+}
+
+// Text writes s in the color of type t.
+func (p *Printer) Text(t Type, s string) {
+	p.send(part{kind: partText, text: s, typ: t, colored: true})
+}
+
+// Value writes v the way spew renders it when it is not part of a block,
+// including its type if shown.  v may also be a reflect.Value.
+func (p *Printer) Value(v interface{}) {
+	if p.stopped {
+		return
+	}
+
+	p.send(part{kind: partInline, node: p.w.child(printerValue(v))})
+}
+
+// Field writes v as a named field of a block, like the fields of a struct.
+// v may also be a reflect.Value.
+func (p *Printer) Field(name string, v interface{}) {
+	if p.stopped || !p.open(false) {
+		return
+	}
+
+	c := p.w.child(printerValue(v))
+	c.Name = name
+	p.send(part{kind: partField, node: c})
+}
+
+// Elem writes v as an element of a block, like the elements of a slice.  v
+// may also be a reflect.Value.
+func (p *Printer) Elem(v interface{}) {
+	if p.stopped || !p.open(true) {
+		return
+	}
+
+	p.elems++
+	if max := p.w.cs.MaxElements; max > 0 && p.elems > max {
+		p.n.More++
+		return
+	}
+
+	p.send(part{kind: partElem, node: p.w.child(printerValue(v))})
+}
+
+// open opens a block of elements or fields unless one is already open.  It
+// returns false if the contents of the block are omitted because the maximum
+// depth is reached.
+func (p *Printer) open(elems bool) bool {
+	if p.opened {
+		return !p.maxDepth
+	}
+	p.opened = true
+
+	p.w.depth++
+	if (p.w.cs.MaxDepth != 0) && (p.w.depth > p.w.cs.MaxDepth) {
+		p.n.MaxDepth = true
+		p.maxDepth = true
+	}
+	p.send(part{kind: partOpen, node: p.n, elems: elems})
+	return !p.maxDepth
+}
+
+// send passes pt to the renderer and records whether the output is written
+// any further.
+func (p *Printer) send(pt part) {
+	if !p.emit(pt) {
+		p.stopped = true
+	}
+}
+
+// close closes the block opened by Field or Elem, if any.
+func (p *Printer) close() {
+	if !p.opened {
+		return
+	}
+
+	p.w.depth--
+	p.send(part{kind: partClose, node: p.n})
+}
+
+// run calls fn to render v and closes the block it opened, if any.  Panics
+// in fn are displayed like those of Stringer and error implementations.
+func (p *Printer) run(fn FormatterFunc, v reflect.Value) {
+	// Custom formatters are likely to call Interface, so bypass the
+	// visibility rules like handleMethods does.
+	if !v.CanInterface() && !UnsafeDisabled {
+		v = unsafeReflectValue(v)
+	}
+
+	func() {
+		defer catchPanic(p, v)
+		fn(p, v)
+	}()
+	p.close()
+}
+
+// spewMethod returns the SpewDump method, or for the custom Formatter if
+// compact is set the SpewFormat method, of the value v as a FormatterFunc
+// along with its receiver, if v implements one.  Methods are looked up the
+// same way handleMethods does.
+func spewMethod(cs *ConfigState, v reflect.Value, compact bool) (FormatterFunc, reflect.Value, bool) {
+	v, ok := methodReceiver(cs, v)
+	if !ok {
+		return nil, v, false
+	}
+
+	var fn func(p *Printer)
+	iface := v.Interface()
+	if f, ok := iface.(SpewFormatter); ok && compact {
+		fn = f.SpewFormat
+	} else if d, ok := iface.(SpewDumper); ok {
+		fn = d.SpewDump
+	} else {
+		return nil, v, false
+	}
+
+	return func(p *Printer, _ reflect.Value) { fn(p) }, v, true
+}
+
+// printerValue returns the reflect.Value of v, or v itself if it already is
+// one.  nil yields a nil interface value, so it is rendered as such.
+func printerValue(v interface{}) reflect.Value {
+	if rv, ok := v.(reflect.Value); ok {
+		return rv
+	}
+
+	return reflect.ValueOf(&v).Elem()
+}
+
+// RegisterFormatter registers fn to render values of type t in Dump style
+// output and the custom Formatter, instead of the way spew renders them
+// itself.  Pointers are followed as usual before fn is called with the value
+// they point to, so t must not be a pointer type.  A nil fn removes the
+// formatter of t.
+//
+// Formatters must be registered before c is used concurrently.
+func (c *ConfigState) RegisterFormatter(t reflect.Type, fn FormatterFunc) {
+	// Copy the registry on every change, so copies of c do not affect each
+	// other.
+	formatters := make(map[reflect.Type]FormatterFunc, len(c.formatters)+1)
+	for typ, f := range c.formatters {
+		formatters[typ] = f
+	}
+
+	if fn == nil {
+		delete(formatters, t)
+	} else {
+		formatters[t] = fn
+	}
+
+	c.formatters = formatters
+}
+
+// formatterFor returns the custom formatter registered for type t, if any.
+func (c *ConfigState) formatterFor(t reflect.Type) FormatterFunc {
+	if c.formatters == nil {
+		return nil
+	}
+
+	return c.formatters[t]
+}
+
+// RegisterFormatter registers fn to render values of type t with the
+// top-level functions.  See ConfigState.RegisterFormatter for details.
+func RegisterFormatter(t reflect.Type, fn FormatterFunc) {
+	Config.RegisterFormatter(t, fn)
+}
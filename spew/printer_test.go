@@ -0,0 +1,171 @@
+package spew_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// money is a type which is rendered by a custom formatter as a single value.
+type money struct {
+	cents int64
+}
+
+// node is a type which is rendered by a custom formatter as a block.
+type node struct {
+	name     string
+	children []*node
+	parent   *node
+}
+
+// boom is a type whose custom formatter panics.
+type boom struct{}
+
+func newPrinterConfig() *spew.ConfigState {
+	cs := &spew.ConfigState{Indent: " ", DisablePointerAddresses: true}
+	cs.RegisterFormatter(reflect.TypeOf(money{}), func(p *spew.Printer, v reflect.Value) {
+		m := v.Interface().(money)
+		p.Text(spew.TFloat, fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100))
+	})
+	cs.RegisterFormatter(reflect.TypeOf(node{}), func(p *spew.Printer, v reflect.Value) {
+		n := v.Interface().(node)
+		p.Field("name", n.name)
+		for _, c := range n.children {
+			p.Elem(c)
+		}
+	})
+	cs.RegisterFormatter(reflect.TypeOf(boom{}), func(p *spew.Printer, v reflect.Value) {
+		panic("boom")
+	})
+	return cs
+}
+
+// TestRegisterFormatter ensures custom formatters render values in Dump and
+// Formatter output, including depth limits and circular references.
+func TestRegisterFormatter(t *testing.T) {
+	cs := newPrinterConfig()
+
+	root := &node{name: "root"}
+	child := &node{name: "child", parent: root}
+	root.children = []*node{child, root}
+
+	depthCs := newPrinterConfig()
+	depthCs.MaxDepth = 1
+
+	unregistered := newPrinterConfig()
+	unregistered.RegisterFormatter(reflect.TypeOf(money{}), nil)
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{cs.Sdump(money{1234}), "(spew_test.money) 12.34\n"},
+		{cs.Sprintf("%v", money{1234}), "12.34"},
+		{cs.Sprintf("%#v", []money{{5}}), "([]spew_test.money)[0.05]"},
+		{cs.Sdump(struct{ M money }{money{100}}), "(struct { M spew_test.money }) {\n" +
+			" M: (spew_test.money) 1.00\n" +
+			"}\n"},
+		{cs.Sdump(child), "(*spew_test.node)({\n" +
+			" name: (string) (len=5) \"child\"\n" +
+			"})\n"},
+		{cs.Sdump(root), "(*spew_test.node)({\n" +
+			" name: (string) (len=4) \"root\",\n" +
+			" (*spew_test.node)({\n" +
+			"  name: (string) (len=5) \"child\"\n" +
+			" }),\n" +
+			" (*spew_test.node)(<already shown>)\n" +
+			"})\n"},
+		{cs.Sprintf("%v", root), "<*>{root <*>{child} <*><shown>}"},
+		{cs.Sprintf("%+v", *child), "{name:child}"},
+		{depthCs.Sdump([]node{*child}), "([]spew_test.node) (len=1 cap=1) {\n" +
+			" (spew_test.node) {\n" +
+			"  <max depth reached>\n" +
+			" }\n" +
+			"}\n"},
+		{depthCs.Sprintf("%v", []node{*child}), "[{<max>}]"},
+		{cs.Sdump(boom{}), "(spew_test.boom) (PANIC=boom)\n"},
+		{unregistered.Sdump(money{1}), "(spew_test.money) {\n" +
+			" cents: (int64) 1\n" +
+			"}\n"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("RegisterFormatter #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+}
+
+// account implements SpewDumper and SpewFormatter.
+type account struct {
+	id    int
+	owner *person
+}
+
+func (a account) SpewDump(p *spew.Printer) {
+	p.Field("ID", a.id)
+	p.Field("Owner", a.owner)
+}
+
+func (a account) SpewFormat(p *spew.Printer) {
+	p.Text(spew.TInteger, fmt.Sprintf("account#%d", a.id))
+}
+
+// person implements SpewDumper with a pointer receiver.
+type person struct {
+	name    string
+	account *account
+}
+
+func (pp *person) SpewDump(p *spew.Printer) {
+	p.Field("Name", pp.name)
+	p.Field("Account", pp.account)
+}
+
+// TestSpewDumper ensures SpewDumper and SpewFormatter implementations render
+// values in Dump and Formatter output, and are subject to the method options.
+func TestSpewDumper(t *testing.T) {
+	cs := &spew.ConfigState{Indent: " ", DisablePointerAddresses: true}
+	noMethods := &spew.ConfigState{DisableMethods: true}
+	noPtrMethods := &spew.ConfigState{Indent: " ", DisablePointerAddresses: true,
+		DisablePointerMethods: true}
+
+	bob := &person{name: "bob"}
+	acc := account{id: 7, owner: bob}
+	bob.account = &acc
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{cs.Sdump(acc), "(spew_test.account) {\n" +
+			" ID: (int) 7,\n" +
+			" Owner: (*spew_test.person)({\n" +
+			"  Name: (string) (len=3) \"bob\",\n" +
+			"  Account: (*spew_test.account)({\n" +
+			"   ID: (int) 7,\n" +
+			"   Owner: (*spew_test.person)(<already shown>)\n" +
+			"  })\n" +
+			" })\n" +
+			"}\n"},
+		{cs.Sprintf("%v", acc), "account#7"},
+		{cs.Sprintf("%v", bob), "<*>{bob <*>account#7}"},
+		{noMethods.Sprintf("%v", person{name: "al"}), "{al <nil>}"},
+		{noPtrMethods.Sdump(person{name: "al"}), "(spew_test.person) {\n" +
+			" name: (string) (len=2) \"al\",\n" +
+			" account: (*spew_test.account)(<nil>)\n" +
+			"}\n"},
+		{noPtrMethods.Sdump(&person{name: "al"}), "(*spew_test.person)({\n" +
+			" Name: (string) (len=2) \"al\",\n" +
+			" Account: (*spew_test.account)(<nil>)\n" +
+			"})\n"},
+	}
+
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("SpewDumper #%d\n got: %s want: %s", i, test.got, test.want)
+		}
+	}
+}
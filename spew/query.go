@@ -0,0 +1,413 @@
+package spew
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// pathStep is a single step of a path query, see DumpPath.
+type pathStep struct {
+	// recursive specifies whether the step applies to the value it starts
+	// from and all of its descendants rather than the value only.
+	recursive bool
+
+	// any specifies whether the step selects all fields, elements and map
+	// entries.
+	any bool
+
+	// field is the name of the struct field selected, if any.
+	field string
+
+	// index is the text between the brackets of an index or map key, e.g.
+	// 2 or "foo".  Quoted keys are normalized to strconv.Quote form, so
+	// they compare equal to the paths built by keyPath.
+	index string
+}
+
+// pathQueryError returns the error for an invalid path query, which is
+// detected at offset pos.
+func pathQueryError(query string, pos int, format string, a ...interface{}) error {
+	return fmt.Errorf("spew: path %q: offset %d: %s", query, pos, fmt.Sprintf(format, a...))
+}
+
+// parsePathQuery splits query into its steps.  An empty query or "." selects
+// the root value.
+func parsePathQuery(query string) ([]pathStep, error) {
+	if query == "." {
+		return nil, nil
+	}
+
+	var steps []pathStep
+	for pos := 0; pos < len(query); {
+		var st pathStep
+		switch {
+		case strings.HasPrefix(query[pos:], ".."):
+			st.recursive = true
+			pos += 2
+			if pos < len(query) && query[pos] == '[' {
+				break
+			}
+			fallthrough
+
+		case query[pos] == '.':
+			if !st.recursive {
+				pos++
+			}
+			name := query[pos:]
+			if i := strings.IndexFunc(name, func(r rune) bool {
+				return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			}); i >= 0 {
+				name = name[:i]
+			}
+			if strings.HasPrefix(query[pos:], "*") {
+				name = "*"
+			}
+			if name == "" {
+				return nil, pathQueryError(query, pos, "expected field name")
+			}
+			pos += len(name)
+			st.field, st.any = name, name == "*"
+			if st.any {
+				st.field = ""
+			}
+			steps = append(steps, st)
+			continue
+
+		case query[pos] != '[':
+			return nil, pathQueryError(query, pos, "expected '.' or '['")
+		}
+
+		// Parse an index or map key, skipping over the brackets inside of
+		// quoted keys.
+		pos++
+		rest := query[pos:]
+		if q, ok := quotedPrefix(rest); ok {
+			key, err := strconv.Unquote(q)
+			if err != nil {
+				return nil, pathQueryError(query, pos, "invalid quoted key %s", q)
+			}
+			st.index = strconv.Quote(key)
+			rest = rest[len(q):]
+			pos += len(q)
+			if !strings.HasPrefix(rest, "]") {
+				return nil, pathQueryError(query, pos, "expected ']'")
+			}
+		} else {
+			i := closingBracket(rest)
+			if i < 0 {
+				return nil, pathQueryError(query, pos-1, "unterminated '['")
+			}
+			if i == 0 {
+				return nil, pathQueryError(query, pos, "expected index or key")
+			}
+			st.index = rest[:i]
+			pos += i
+		}
+		pos++
+
+		if st.index == "*" {
+			st.index, st.any = "", true
+		}
+		steps = append(steps, st)
+	}
+
+	return steps, nil
+}
+
+// closingBracket returns the index of the ']' closing the index or key s
+// starts with, or -1 if there is none.  Keys which are Go literals may
+// contain brackets themselves, e.g. [[2]int{1, 2}].
+func closingBracket(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+
+	return -1
+}
+
+// pathMatch is a value selected by a path query.
+type pathMatch struct {
+	path string
+	v    reflect.Value
+
+	// redact specifies whether the value is a redacted struct field.
+	redact bool
+}
+
+// pathSelector contains information about the state of the selection of
+// values by a path query.
+type pathSelector struct {
+	cs      *ConfigState
+	matches []pathMatch
+}
+
+// resolve follows the pointers and unpacks the interfaces v is reached
+// through, like the walk of Dump does.  It returns an invalid value if visit
+// is set and returns false for a pointer followed.
+func resolvePath(v reflect.Value, visit func(addr uintptr) bool) reflect.Value {
+	for {
+		switch v.Kind() {
+		case reflect.Interface:
+			if v.IsNil() {
+				return v
+			}
+			v = v.Elem()
+
+		case reflect.Ptr:
+			if v.IsNil() {
+				return v
+			}
+			if visit != nil && !visit(v.Pointer()) {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+
+		default:
+			return v
+		}
+	}
+}
+
+// selectPath selects the values reached from v, which is reached by path,
+// by steps.
+func (s *pathSelector) selectPath(steps []pathStep, path string, v reflect.Value, redact bool) {
+	if len(steps) == 0 {
+		if v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+		s.matches = append(s.matches, pathMatch{path: path, v: v, redact: redact})
+		return
+	}
+
+	// Redacted values are never looked into.
+	if redact {
+		return
+	}
+
+	next := func(path string, c reflect.Value, redact bool) {
+		s.selectPath(steps[1:], path, c, redact)
+	}
+	if !steps[0].recursive {
+		s.step(steps[0], path, v, next)
+		return
+	}
+
+	// Recursive descent follows each pointer only once, so circular data
+	// structures are handled properly.
+	visited := make(map[uintptr]bool)
+	visit := func(addr uintptr) bool {
+		if visited[addr] {
+			return false
+		}
+		visited[addr] = true
+		return true
+	}
+
+	var descend func(path string, v reflect.Value, redact bool)
+	descend = func(path string, v reflect.Value, redact bool) {
+		if redact {
+			return
+		}
+		v = resolvePath(v, visit)
+		if !v.IsValid() {
+			return
+		}
+		s.step(steps[0], path, v, next)
+		s.children(path, v, descend)
+	}
+	descend(path, v, false)
+}
+
+// step passes the values selected from v, which is reached by path, by the
+// single step st to fn.
+func (s *pathSelector) step(st pathStep, path string, v reflect.Value, fn func(path string, c reflect.Value, redact bool)) {
+	v = resolvePath(v, nil)
+	if st.any {
+		s.children(path, v, fn)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if st.field == "" {
+			return
+		}
+		for _, field := range s.cs.shownFields(v, path) {
+			if name := v.Type().Field(field.index).Name; name == st.field {
+				fn(fieldPath(path, name), v.Field(field.index), field.redact)
+			}
+		}
+
+	case reflect.Array, reflect.Slice:
+		if i, err := strconv.Atoi(st.index); err == nil && i >= 0 && i < v.Len() {
+			fn(indexPath(path, i), v.Index(i), false)
+		}
+
+	case reflect.Map:
+		if st.index == "" {
+			return
+		}
+		want := "[" + st.index + "]"
+		for _, key := range s.mapKeys(v) {
+			if keyPath(s.cs, "", key) == want {
+				fn(keyPath(s.cs, path, key), v.MapIndex(key), false)
+			}
+		}
+	}
+}
+
+// children passes the fields, elements or map values of v, which is reached
+// by path, to fn.  v must be resolved already.
+func (s *pathSelector) children(path string, v reflect.Value, fn func(path string, c reflect.Value, redact bool)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		vt := v.Type()
+		for _, field := range s.cs.shownFields(v, path) {
+			fn(fieldPath(path, vt.Field(field.index).Name), v.Field(field.index), field.redact)
+		}
+
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			fn(indexPath(path, i), v.Index(i), false)
+		}
+
+	case reflect.Map:
+		for _, key := range s.mapKeys(v) {
+			fn(keyPath(s.cs, path, key), v.MapIndex(key), false)
+		}
+	}
+}
+
+// mapKeys returns the keys of the map v, which are sorted if SortKeys is
+// set.
+func (s *pathSelector) mapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	if s.cs.SortKeys {
+		sortValues(keys, s.cs)
+	}
+
+	return keys
+}
+
+// fdumpPath is a helper function to consolidate the logic from the various
+// public methods which take varying writers and config states.
+func fdumpPath(cs *ConfigState, w io.Writer, v interface{}, query string) error {
+	steps, err := parsePathQuery(query)
+	if err != nil {
+		return err
+	}
+
+	s := pathSelector{cs: cs}
+	s.selectPath(steps, "", reflect.ValueOf(&v).Elem(), false)
+	if len(s.matches) == 0 {
+		return fmt.Errorf("spew: path %q: no value at path", query)
+	}
+
+	// The limit applies to the output of all matches, so it is enforced
+	// beneath their colorWriters.
+	lvl := cs.colorLevel(w)
+	var lw *limitWriter
+	if cs.MaxOutputBytes > 0 {
+		lw = newLimitWriter(w, cs.MaxOutputBytes, lvl != levelNone, outputTruncatedDumpBytes)
+		w = lw
+	}
+
+	for _, m := range s.matches {
+		if limitFull(lw) {
+			break
+		}
+
+		cw := newColorWriter(w, cs, lvl)
+		d := dumpState{w: cw, cs: cs, cw: cw, lw: lw}
+		path := m.path
+		if path == "" {
+			path = "."
+		}
+		d.writeColored(TFieldName, []byte(path))
+		d.w.Write(colonSpaceBytes)
+		if m.redact {
+			d.writeColored(TRedacted, redactedBytes)
+		} else {
+			// Paths of shared pointers and FieldRules, as well as
+			// the expressions of AnnotatePaths, refer to the root
+			// value rather than the match.
+			w := d.walk()
+			w.path = m.path
+			if w.expr.expr != "" {
+				w.expr.expr += m.path
+			}
+			d.dump(m.v)
+		}
+		d.w.Write(newlineBytes)
+	}
+
+	return nil
+}
+
+// FdumpPath dumps the values selected from v by the path query to
+// io.Writer w.  It dumps exactly the same as DumpPath.
+func FdumpPath(w io.Writer, v interface{}, query string) error {
+	return fdumpPath(&Config, w, v, query)
+}
+
+// SdumpPath returns a string with the values selected from v by the path
+// query dumped exactly the same as DumpPath.
+func SdumpPath(v interface{}, query string) (string, error) {
+	var buf bytes.Buffer
+	err := fdumpPath(&Config, &buf, v, query)
+	return buf.String(), err
+}
+
+/*
+DumpPath dumps only the values selected from v by a path query to standard
+out, each on a line of its own prefixed with the path it is reached by:
+
+	.Spec.Containers[2].Env: ([]main.EnvVar) (len=1 cap=1) {
+	 ...
+	}
+
+Queries are written like the paths Diff reports and consist of the following
+steps, which are applied one after another:
+
+	.Name     the struct field Name
+	[2]       the element at index 2 of an array or slice
+	["key"]   the entry of a map with the string key "key"
+	[42]      the entry of a map with a key written as the Go literal 42,
+	          e.g. [2.5], [main.Point{X: 1}] or, for pointer keys, their
+	          type and address like [(*main.T)(0xc000010000)]
+	.* [*]    all fields, elements or map entries
+	..Name    the step following the dots applied to the value and all
+	          of its descendants, e.g. ..Env or ..[0]
+
+Pointers and interfaces are followed like Dump does, so they are never part of
+a query, and recursive descent visits each pointer only once.  Struct fields
+skipped by the spew struct tag or a FieldRule are never selected, redacted ones
+are displayed as <redacted>.  An empty query or "." selects v itself.  Values
+are selected in the order Dump displays them, so map entries are only in a
+stable order if SortKeys is set.  An error is returned if the query is invalid
+or selects no value, in which case nothing is dumped.
+
+The configuration options are controlled by an exported package global,
+spew.Config.  See ConfigState for options documentation.
+
+See FdumpPath if you would prefer dumping to an arbitrary io.Writer or
+SdumpPath to get the formatted result as a string.
+*/
+func DumpPath(v interface{}, query string) error {
+	return fdumpPath(&Config, os.Stdout, v, query)
+}
@@ -0,0 +1,153 @@
+package spew_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// queryEnv, queryContainer and querySpec are selected from by TestDumpPath.
+type queryEnv struct {
+	Name  string
+	Value string
+}
+
+type queryContainer struct {
+	Image string
+	Env   []queryEnv
+}
+
+type queryPoint struct {
+	X, Y int
+}
+
+type querySpec struct {
+	Containers []*queryContainer
+	Labels     map[string]interface{}
+	Ports      map[int]string
+	Token      string `spew:"redact"`
+	Parent     *querySpec
+	Points     map[queryPoint]string
+	Grid       map[[2]int]float64
+	Scales     map[float64]string
+	Owners     map[*queryEnv]string
+}
+
+// TestDumpPath ensures path queries select the values they describe and dump
+// each one prefixed with its path.
+func TestDumpPath(t *testing.T) {
+	v := &querySpec{
+		Containers: []*queryContainer{
+			{Image: "a", Env: []queryEnv{{"A", "1"}}},
+			{Image: "b"},
+		},
+		Labels: map[string]interface{}{"app": "web", "tier": 2},
+		Ports:  map[int]string{80: "http"},
+		Token:  "secret",
+		Points: map[queryPoint]string{{1, 2}: "a", {12, 0}: "b"},
+		Grid:   map[[2]int]float64{{1, 2}: 0.5},
+		Scales: map[float64]string{2: "double"},
+	}
+	v.Parent = v
+	owner := &queryEnv{Name: "o"}
+	v.Owners = map[*queryEnv]string{owner: "owner", {Name: "o"}: "other"}
+	ownerQuery := fmt.Sprintf(".Owners[(*spew_test.queryEnv)(%p)]", owner)
+
+	cs := &spew.ConfigState{Indent: " ", SortKeys: true, DisablePointerAddresses: true,
+		DisableCapacities: true}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{".Containers[0].Env[0].Name", ".Containers[0].Env[0].Name: (string) (len=1) \"A\"\n"},
+		{".Containers[1]", ".Containers[1]: (*spew_test.queryContainer)({\n" +
+			" Image: (string) (len=1) \"b\",\n" +
+			" Env: ([]spew_test.queryEnv) <nil>\n" +
+			"})\n"},
+		{".Containers[*].Image", ".Containers[0].Image: (string) (len=1) \"a\"\n" +
+			".Containers[1].Image: (string) (len=1) \"b\"\n"},
+		{`.Labels["tier"]`, ".Labels[\"tier\"]: (int) 2\n"},
+		{".Ports[80]", ".Ports[80]: (string) (len=4) \"http\"\n"},
+		{".Containers[0].*", ".Containers[0].Image: (string) (len=1) \"a\"\n" +
+			".Containers[0].Env: ([]spew_test.queryEnv) (len=1) {\n" +
+			" (spew_test.queryEnv) {\n" +
+			"  Name: (string) (len=1) \"A\",\n" +
+			"  Value: (string) (len=1) \"1\"\n" +
+			" }\n" +
+			"}\n"},
+		{".Labels[*]", ".Labels[\"app\"]: (string) (len=3) \"web\"\n" +
+			".Labels[\"tier\"]: (int) 2\n"},
+		{"..Image", ".Containers[0].Image: (string) (len=1) \"a\"\n" +
+			".Containers[1].Image: (string) (len=1) \"b\"\n"},
+		{"..Token", ".Token: <redacted>\n"},
+		{".Parent.Parent.Ports", ".Parent.Parent.Ports: (map[int]string) (len=1) {\n" +
+			" (int) 80: (string) (len=4) \"http\"\n" +
+			"}\n"},
+		{".Points[spew_test.queryPoint{X: 1, Y: 2}]",
+			".Points[spew_test.queryPoint{X: 1, Y: 2}]: (string) (len=1) \"a\"\n"},
+		{".Grid[*]", ".Grid[[2]int{1, 2}]: (float64) 0.5\n"},
+		{".Grid[[2]int{1, 2}]", ".Grid[[2]int{1, 2}]: (float64) 0.5\n"},
+		{".Scales[2.0]", ".Scales[2.0]: (string) (len=6) \"double\"\n"},
+		{ownerQuery, ownerQuery + ": (string) (len=5) \"owner\"\n"},
+
+		// queries selecting nothing
+		{".Containers[2]", ""},
+		{".Missing", ""},
+		{".Token.Foo", ""},
+		{".Scales[2]", ""},
+	}
+
+	for i, test := range tests {
+		got, err := cs.SdumpPath(v, test.query)
+		if test.want == "" {
+			want := fmt.Sprintf("spew: path %q: no value at path", test.query)
+			if err == nil || err.Error() != want || got != "" {
+				t.Errorf("DumpPath #%d %s\n got: %q, %v want: %s", i, test.query, got, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("DumpPath #%d %s: unexpected error %v", i, test.query, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("DumpPath #%d %s\n got: %s want: %s", i, test.query, got, test.want)
+		}
+	}
+
+	got, _ := cs.SdumpPath(1, ".")
+	if want := ".: (int) 1\n"; got != want {
+		t.Errorf("DumpPath root\n got: %s want: %s", got, want)
+	}
+}
+
+// TestDumpPathErrors ensures invalid path queries are rejected.
+func TestDumpPathErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"Foo", `spew: path "Foo": offset 0: expected '.' or '['`},
+		{".", ""},
+		{".Foo.", `spew: path ".Foo.": offset 5: expected field name`},
+		{"..", `spew: path "..": offset 2: expected field name`},
+		{"[1", `spew: path "[1": offset 0: unterminated '['`},
+		{"[]", `spew: path "[]": offset 1: expected index or key`},
+		{`["a"`, `spew: path "[\"a\"": offset 4: expected ']'`},
+		{"[[2]int{1, 2}", `spew: path "[[2]int{1, 2}": offset 0: unterminated '['`},
+		{".Foo", `spew: path ".Foo": no value at path`},
+	}
+
+	for i, test := range tests {
+		_, err := spew.SdumpPath(nil, test.query)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != test.want {
+			t.Errorf("DumpPathErrors #%d\n got: %s want: %s", i, got, test.want)
+		}
+	}
+}
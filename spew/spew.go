@@ -19,6 +19,7 @@ package spew
 import (
 	"fmt"
 	"io"
+	"os"
 )
 
 // Errorf is a wrapper for fmt.Errorf that treats each argument as if it were
@@ -30,7 +31,7 @@ import (
 //
 //	fmt.Errorf(format, spew.NewFormatter(a), spew.NewFormatter(b))
 func Errorf(format string, a ...interface{}) (err error) {
-	return fmt.Errorf(format, convertArgs(a)...)
+	return fmt.Errorf(format, convertArgs(nil, a)...)
 }
 
 // Fprint is a wrapper for fmt.Fprint that treats each argument as if it were
@@ -42,7 +43,7 @@ func Errorf(format string, a ...interface{}) (err error) {
 //
 //	fmt.Fprint(w, spew.NewFormatter(a), spew.NewFormatter(b))
 func Fprint(w io.Writer, a ...interface{}) (n int, err error) {
-	return fmt.Fprint(w, convertArgs(a)...)
+	return fmt.Fprint(w, convertArgs(w, a)...)
 }
 
 // Fprintf is a wrapper for fmt.Fprintf that treats each argument as if it were
@@ -54,7 +55,7 @@ func Fprint(w io.Writer, a ...interface{}) (n int, err error) {
 //
 //	fmt.Fprintf(w, format, spew.NewFormatter(a), spew.NewFormatter(b))
 func Fprintf(w io.Writer, format string, a ...interface{}) (n int, err error) {
-	return fmt.Fprintf(w, format, convertArgs(a)...)
+	return fmt.Fprintf(w, format, convertArgs(w, a)...)
 }
 
 // Fprintln is a wrapper for fmt.Fprintln that treats each argument as if it
@@ -65,7 +66,7 @@ func Fprintf(w io.Writer, format string, a ...interface{}) (n int, err error) {
 //
 //	fmt.Fprintln(w, spew.NewFormatter(a), spew.NewFormatter(b))
 func Fprintln(w io.Writer, a ...interface{}) (n int, err error) {
-	return fmt.Fprintln(w, convertArgs(a)...)
+	return fmt.Fprintln(w, convertArgs(w, a)...)
 }
 
 // Print is a wrapper for fmt.Print that treats each argument as if it were
@@ -77,7 +78,7 @@ func Fprintln(w io.Writer, a ...interface{}) (n int, err error) {
 //
 //	fmt.Print(spew.NewFormatter(a), spew.NewFormatter(b))
 func Print(a ...interface{}) (n int, err error) {
-	return fmt.Print(convertArgs(a)...)
+	return fmt.Print(convertArgs(os.Stdout, a)...)
 }
 
 // Printf is a wrapper for fmt.Printf that treats each argument as if it were
@@ -89,7 +90,7 @@ func Print(a ...interface{}) (n int, err error) {
 //
 //	fmt.Printf(format, spew.NewFormatter(a), spew.NewFormatter(b))
 func Printf(format string, a ...interface{}) (n int, err error) {
-	return fmt.Printf(format, convertArgs(a)...)
+	return fmt.Printf(format, convertArgs(os.Stdout, a)...)
 }
 
 // Println is a wrapper for fmt.Println that treats each argument as if it were
@@ -101,7 +102,7 @@ func Printf(format string, a ...interface{}) (n int, err error) {
 //
 //	fmt.Println(spew.NewFormatter(a), spew.NewFormatter(b))
 func Println(a ...interface{}) (n int, err error) {
-	return fmt.Println(convertArgs(a)...)
+	return fmt.Println(convertArgs(os.Stdout, a)...)
 }
 
 // Sprint is a wrapper for fmt.Sprint that treats each argument as if it were
@@ -112,7 +113,7 @@ func Println(a ...interface{}) (n int, err error) {
 //
 //	fmt.Sprint(spew.NewFormatter(a), spew.NewFormatter(b))
 func Sprint(a ...interface{}) string {
-	return fmt.Sprint(convertArgs(a)...)
+	return fmt.Sprint(convertArgs(nil, a)...)
 }
 
 // Sprintf is a wrapper for fmt.Sprintf that treats each argument as if it were
@@ -123,7 +124,7 @@ func Sprint(a ...interface{}) string {
 //
 //	fmt.Sprintf(format, spew.NewFormatter(a), spew.NewFormatter(b))
 func Sprintf(format string, a ...interface{}) string {
-	return fmt.Sprintf(format, convertArgs(a)...)
+	return fmt.Sprintf(format, convertArgs(nil, a)...)
 }
 
 // Sprintln is a wrapper for fmt.Sprintln that treats each argument as if it
@@ -134,15 +135,13 @@ func Sprintf(format string, a ...interface{}) string {
 //
 //	fmt.Sprintln(spew.NewFormatter(a), spew.NewFormatter(b))
 func Sprintln(a ...interface{}) string {
-	return fmt.Sprintln(convertArgs(a)...)
+	return fmt.Sprintln(convertArgs(nil, a)...)
 }
 
 // convertArgs accepts a slice of arguments and returns a slice of the same
 // length with each argument converted to a default spew Formatter interface.
-func convertArgs(args []interface{}) (formatters []interface{}) {
-	formatters = make([]interface{}, len(args))
-	for index, arg := range args {
-		formatters[index] = NewFormatter(arg)
-	}
-	return formatters
+// The formatters assume their output is written to w, which may be nil if it
+// is unknown.
+func convertArgs(w io.Writer, args []interface{}) (formatters []interface{}) {
+	return Config.convertArgs(w, args)
 }
@@ -132,6 +132,8 @@ func initSpewTests() {
 	scsContinue := &spew.ConfigState{Indent: " ", ContinueOnMethod: true}
 	scsNoPtrAddr := &spew.ConfigState{DisablePointerAddresses: true}
 	scsNoCap := &spew.ConfigState{DisableCapacities: true}
+	scsPtrIDs := &spew.ConfigState{Indent: " ", PointerIDs: true, DisablePointerAddresses: true}
+	scsShared := &spew.ConfigState{Indent: " ", DetectSharedPointers: true, DisablePointerAddresses: true}
 
 	// Variables for tests on types which implement Stringer interface with and
 	// without a pointer receiver.
@@ -161,6 +163,26 @@ func initSpewTests() {
 	// Variable for tests on types which implement error interface.
 	te := customError(10)
 
+	// Variables for tests on pointer ids.  The struct values are shared and
+	// circular.
+	type ptrNode struct {
+		next  *ptrNode
+		other *ptrNode
+	}
+	pn1 := &ptrNode{}
+	pn2 := &ptrNode{next: pn1}
+	pn1.next, pn1.other = pn2, pn2
+	pi := 5
+	ppi := &pi
+
+	// Variables for tests on shared pointers.
+	type sharedTester struct {
+		Nodes []*ptrNode
+		Last  *ptrNode
+	}
+	sn := &ptrNode{}
+	st := sharedTester{Nodes: []*ptrNode{{other: sn}, sn}, Last: sn}
+
 	spewTests = []spewTest{
 		{scsDefault, fCSFdump, "", int8(127), "(int8) 127\n"},
 		{scsDefault, fCSFprint, "", int16(32767), "32767"},
@@ -207,6 +229,41 @@ func initSpewTests() {
 		{scsNoPtrAddr, fCSSdump, "", tptr, "(*spew_test.ptrTester)({\ns: (*struct {})({\n})\n})\n"},
 		{scsNoCap, fCSSdump, "", make([]string, 0, 10), "([]string) {\n}\n"},
 		{scsNoCap, fCSSdump, "", make([]string, 1, 10), "([]string) (len=1) {\n(string) \"\"\n}\n"},
+		{scsPtrIDs, fCSSdump, "", pn1, "(*spew_test.ptrNode)(&1)({\n" +
+			" next: (*spew_test.ptrNode)(&2)({\n" +
+			"  next: (*spew_test.ptrNode)(-> &1),\n" +
+			"  other: (*spew_test.ptrNode)(<nil>)\n" +
+			" }),\n" +
+			" other: (*spew_test.ptrNode)(-> &2)\n" +
+			"})\n"},
+		{scsPtrIDs, fCSSdump, "", []interface{}{&ppi, ppi}, "([]interface {}) (len=2 cap=2) {\n" +
+			" (**int)(&1->&2)(5),\n" +
+			" (*int)(-> &2)\n" +
+			"}\n"},
+		{scsPtrIDs, fCSFprint, "", pn1, "<*>(&1){<*>(&2){<*>-> &1 <nil>} <*>-> &2}"},
+		{scsPtrIDs, fCSSprintf, "%#v", []*int{ppi, ppi}, "([]*int)[<*>(&1)5 <*>-> &1]"},
+		{scsShared, fCSSdump, "", st, "(spew_test.sharedTester) {\n" +
+			" Nodes: ([]*spew_test.ptrNode) (len=2 cap=2) {\n" +
+			"  (*spew_test.ptrNode)({\n" +
+			"   next: (*spew_test.ptrNode)(<nil>),\n" +
+			"   other: (*spew_test.ptrNode)({\n" +
+			"    next: (*spew_test.ptrNode)(<nil>),\n" +
+			"    other: (*spew_test.ptrNode)(<nil>)\n" +
+			"   })\n" +
+			"  }),\n" +
+			"  (*spew_test.ptrNode)(<same as .Nodes[0].other>)\n" +
+			" },\n" +
+			" Last: (*spew_test.ptrNode)(<same as .Nodes[0].other>)\n" +
+			"}\n"},
+		{scsShared, fCSSdump, "", pn1, "(*spew_test.ptrNode)({\n" +
+			" next: (*spew_test.ptrNode)({\n" +
+			"  next: (*spew_test.ptrNode)(<same as .>),\n" +
+			"  other: (*spew_test.ptrNode)(<nil>)\n" +
+			" }),\n" +
+			" other: (*spew_test.ptrNode)(<same as .next>)\n" +
+			"})\n"},
+		{scsShared, fCSFprint, "", st, "{[<*>{<nil> <*>{<nil> <nil>}} <*><same as .Nodes[0].other>] " +
+			"<*><same as .Nodes[0].other>}"},
 	}
 }
 
@@ -0,0 +1,101 @@
+//go:build go1.21
+// +build go1.21
+
+// Package spewlog integrates spew with the structured logging of log/slog.
+//
+// Values logged with Any are rendered by spew only if the record is actually
+// handled, that is if its level is enabled:
+//
+//	logger.Debug("request received", spewlog.Any("req", req))
+//
+// Text handlers display the compact form of the spew Formatter, JSON
+// handlers the JSON tree of spew.Sjson.
+//
+// The package requires log/slog and is therefore only built with Go 1.21 and
+// later, although the module supports older versions.  It is empty when built
+// with an older toolchain.
+package spewlog
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// Config is the configuration Any renders values with.  Its defaults leave
+// out pointer addresses, which are rarely useful in logs, and sort map keys,
+// so equal values are logged the same.  Colors are never emitted.
+var Config = spew.ConfigState{
+	DisablePointerAddresses: true,
+	SortKeys:                true,
+}
+
+// Value wraps a value which is rendered by spew when it is logged or
+// formatted.  It implements slog.LogValuer and fmt.Formatter.  The zero
+// Value renders nil with spew.Config.
+type Value struct {
+	cs *spew.ConfigState
+	v  interface{}
+}
+
+// config returns the configuration v is rendered with.
+func (v Value) config() *spew.ConfigState {
+	if v.cs == nil {
+		return &spew.Config
+	}
+	return v.cs
+}
+
+// NewValue returns a Value which renders v with the configuration cs, or
+// spew.Config if cs is nil.
+func NewValue(cs *spew.ConfigState, v interface{}) Value {
+	return Value{cs: cs, v: v}
+}
+
+// Any returns an attribute with the passed key whose value v is rendered by
+// spew with Config, see Value.
+func Any(key string, v interface{}) slog.Attr {
+	return AnyConfig(&Config, key, v)
+}
+
+// AnyConfig returns an attribute with the passed key whose value v is
+// rendered by spew with the configuration cs, see Value.
+func AnyConfig(cs *spew.ConfigState, key string, v interface{}) slog.Attr {
+	return slog.Any(key, NewValue(cs, v))
+}
+
+// LogValue implements slog.LogValuer.  The value returned is only rendered
+// when the handler writes it, in the form suited for the handler: text
+// handlers display the compact form of the Formatter, JSON handlers the JSON
+// tree of Sjson.
+func (v Value) LogValue() slog.Value {
+	return slog.AnyValue(rendered{cs: v.config(), v: v.v})
+}
+
+// Format implements fmt.Formatter like the Formatter returned by
+// NewFormatter of the configuration of v does.
+func (v Value) Format(f fmt.State, verb rune) {
+	v.config().NewFormatter(v.v).Format(f, verb)
+}
+
+// rendered is the value of a resolved Value, which is rendered by the
+// handler through the encoding interfaces.  It is not a slog.LogValuer,
+// which would be resolved again.
+type rendered Value
+
+// MarshalText implements encoding.TextMarshaler, which text handlers use.
+func (r rendered) MarshalText() ([]byte, error) {
+	return []byte(r.cs.Sprint(r.v)), nil
+}
+
+// MarshalJSON implements json.Marshaler, which JSON handlers use.
+func (r rendered) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.cs.Fjson(&buf, r.v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,123 @@
+//go:build go1.21
+// +build go1.21
+
+package spewlog_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+	"github.com/l0nax/go-spew/spew/spewlog"
+)
+
+type request struct {
+	Path   string
+	Header map[string][]string
+}
+
+// counter counts the calls of its String method.
+type counter struct {
+	name  string
+	calls *int
+}
+
+func (c counter) String() string {
+	*c.calls++
+	return c.name
+}
+
+// dropTime removes the time from the records, so the output is the same on
+// every run.
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
+
+func TestAny(t *testing.T) {
+	v := &request{Path: "/users", Header: map[string][]string{"B": {"2"}, "A": {"1"}}}
+	opts := &slog.HandlerOptions{ReplaceAttr: dropTime}
+
+	tests := []struct {
+		name    string
+		handler func(buf *bytes.Buffer) slog.Handler
+		want    string
+	}{
+		{
+			"text",
+			func(buf *bytes.Buffer) slog.Handler { return slog.NewTextHandler(buf, opts) },
+			`level=INFO msg=handled req="<*>{/users map[A:[1] B:[2]]}"` + "\n",
+		},
+		{
+			"json",
+			func(buf *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(buf, opts) },
+			`{"level":"INFO","msg":"handled","req":{"type":"*spewlog_test.request","fields":[` +
+				`{"name":"Path","value":{"type":"string","len":6,"value":"/users"}},` +
+				`{"name":"Header","value":{"type":"map[string][]string","len":2,"entries":[` +
+				`{"key":{"type":"string","len":1,"value":"A"},"value":{"type":"[]string","len":1,"cap":1,"elems":[{"type":"string","len":1,"value":"1"}]}},` +
+				`{"key":{"type":"string","len":1,"value":"B"},"value":{"type":"[]string","len":1,"cap":1,"elems":[{"type":"string","len":1,"value":"2"}]}}` +
+				`]}}]}}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		slog.New(test.handler(&buf)).Info("handled", spewlog.Any("req", v))
+		if s := buf.String(); s != test.want {
+			t.Errorf("Any %s\n got: %s want: %s", test.name, s, test.want)
+		}
+	}
+}
+
+func TestAnyLazy(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: dropTime}))
+
+	calls := 0
+	logger.Debug("skipped", spewlog.Any("v", counter{"counter", &calls}))
+	if calls != 0 || buf.Len() != 0 {
+		t.Errorf("Any rendered disabled record: %d calls, output %q", calls, buf.String())
+	}
+
+	logger.Info("handled", spewlog.Any("v", counter{"counter", &calls}))
+	if want := "level=INFO msg=handled v=counter\n"; calls != 1 || buf.String() != want {
+		t.Errorf("Any\n got: %d calls %q want: 1 calls %q", calls, buf.String(), want)
+	}
+}
+
+func TestValueFormat(t *testing.T) {
+	cs := spew.ConfigState{DisablePointerAddresses: true}
+	v := spewlog.NewValue(&cs, &request{Path: "/"})
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%v", "<*>{/ <nil>}"},
+		{"%#v", "(*spewlog_test.request){Path:(string)/ Header:(map[string][]string)<nil>}"},
+	}
+
+	for i, test := range tests {
+		if s := fmt.Sprintf(test.format, v); s != test.want {
+			t.Errorf("Format #%d %s\n got: %s want: %s", i, test.format, s, test.want)
+		}
+	}
+}
+
+func TestValueZero(t *testing.T) {
+	var v spewlog.Value
+	if s, want := fmt.Sprintf("%v", v), "<nil>"; s != want {
+		t.Errorf("Format zero Value\n got: %s want: %s", s, want)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: dropTime})).Info("zero", "v", v)
+	want := `{"level":"INFO","msg":"zero","v":{"type":"interface {}","nil":true}}` + "\n"
+	if s := buf.String(); s != want {
+		t.Errorf("LogValue zero Value\n got: %s want: %s", s, want)
+	}
+}
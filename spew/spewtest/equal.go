@@ -0,0 +1,173 @@
+package spewtest
+
+import (
+	"math"
+	"reflect"
+)
+
+// visit identifies a comparison of two values reached through pointers, maps
+// or slices, which is assumed to be equal while it is in progress so circular
+// data structures terminate.
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// equal returns whether a and b are deeply equal.  They are compared like
+// reflect.DeepEqual does, except that NaN is equal to NaN, also as a map key.
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return deepEqual(reflect.ValueOf(a), reflect.ValueOf(b), make(map[visit]bool))
+}
+
+// deepEqual returns whether a and b are deeply equal, see equal.
+func deepEqual(a, b reflect.Value, visited map[visit]bool) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Kind() == reflect.Slice && a.Len() != b.Len() {
+			return false
+		}
+		if a.Pointer() == b.Pointer() {
+			return true
+		}
+
+		v := visit{a.Pointer(), b.Pointer(), a.Type()}
+		if visited[v] {
+			return true
+		}
+		visited[v] = true
+	}
+
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+
+	case reflect.Float32, reflect.Float64:
+		return floatEqual(a.Float(), b.Float())
+
+	case reflect.Complex64, reflect.Complex128:
+		ca, cb := a.Complex(), b.Complex()
+		return floatEqual(real(ca), real(cb)) && floatEqual(imag(ca), imag(cb))
+
+	case reflect.String:
+		return a.String() == b.String()
+
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+
+	case reflect.Func:
+		// Functions are only equal if both are nil, like reflect.DeepEqual.
+		return a.IsNil() && b.IsNil()
+
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return deepEqual(a.Elem(), b.Elem(), visited)
+
+	case reflect.Ptr:
+		return deepEqual(a.Elem(), b.Elem(), visited)
+
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < a.Len(); i++ {
+			if !deepEqual(a.Index(i), b.Index(i), visited) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !deepEqual(a.Field(i), b.Field(i), visited) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Map:
+		return mapEqual(a, b, visited)
+	}
+
+	return false
+}
+
+// floatEqual returns whether a and b are equal or both NaN.
+func floatEqual(a, b float64) bool {
+	return a == b || math.IsNaN(a) && math.IsNaN(b)
+}
+
+// mapEqual returns whether the non-nil maps a and b are deeply equal.  Keys
+// which are not equal to themselves, such as NaN, can not be looked up, so
+// their entries are paired with the deeply equal entries of the other map.
+func mapEqual(a, b reflect.Value, visited map[visit]bool) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	type entry struct {
+		key, value reflect.Value
+	}
+	var nanA, nanB []entry
+
+	iter := a.MapRange()
+	for iter.Next() {
+		key := iter.Key()
+		if bv := b.MapIndex(key); bv.IsValid() {
+			if !deepEqual(iter.Value(), bv, visited) {
+				return false
+			}
+			continue
+		}
+		if a.MapIndex(key).IsValid() {
+			return false
+		}
+		nanA = append(nanA, entry{key, iter.Value()})
+	}
+
+	iter = b.MapRange()
+	for iter.Next() {
+		if !b.MapIndex(iter.Key()).IsValid() {
+			nanB = append(nanB, entry{iter.Key(), iter.Value()})
+		}
+	}
+	if len(nanA) != len(nanB) {
+		return false
+	}
+
+	// Deep equality is transitive, so pairing each entry with the first
+	// equal one left never misses a complete pairing.
+	for _, ea := range nanA {
+		found := false
+		for i, eb := range nanB {
+			if deepEqual(ea.key, eb.key, visited) && deepEqual(ea.value, eb.value, visited) {
+				nanB = append(nanB[:i], nanB[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,177 @@
+package spewtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// update specifies whether Snapshot rewrites the golden files.  The flag
+// is prefixed with the package name, so it does not collide with an -update
+// flag of the package under test.
+var update = flag.Bool("spewtest.update", false, "rewrite the golden files of spewtest.Snapshot")
+
+// SnapshotConfig is the configuration Snapshot dumps values with.  Its
+// defaults produce the same output on every run: pointers are displayed as
+// ordinals instead of addresses, map keys are sorted and colors are never
+// emitted.
+var SnapshotConfig = spew.ConfigState{
+	Indent:     " ",
+	PointerIDs: true,
+	SortKeys:   true,
+	ColorMode:  spew.ColorNever,
+}
+
+// goldenPath returns the path of the golden file of the snapshot name of the
+// test t.
+func goldenPath(t testing.TB, name string) string {
+	return filepath.Join("testdata", filepath.FromSlash(t.Name()), name+".golden")
+}
+
+/*
+Snapshot compares the dump of v to the golden file
+testdata/<test>/<name>.golden, where <test> is the name of the test t, and
+reports an error if they differ.  It returns whether they are equal.
+
+The value is dumped with SnapshotConfig, so the output is the same on every
+run.  If the test is run with the -spewtest.update flag, the golden file is
+written instead:
+
+	go test -run TestParse -spewtest.update
+
+A mismatch is reported as the lines of the golden file which are missing,
+prefixed with -, and the lines of the dump which are new, prefixed with +:
+
+	spewtest: snapshot testdata/TestParse/config.golden differs (-golden +got):
+	  (main.Config) {
+	-  Port: (int) 80,
+	+  Port: (int) 8080,
+	   Host: (string) (len=9) "localhost"
+	  }
+*/
+func Snapshot(t testing.TB, name string, v interface{}) bool {
+	t.Helper()
+
+	got := SnapshotConfig.Sdump(v)
+	path := goldenPath(t, name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("spewtest: snapshot %s: %v", path, err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Errorf("spewtest: snapshot %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("spewtest: snapshot %s: %v (run with -spewtest.update to create it)", path, err)
+		return false
+	}
+	if string(want) == got {
+		return true
+	}
+
+	t.Errorf("spewtest: snapshot %s differs (-golden +got):\n%s", path, lineDiff(string(want), got))
+	return false
+}
+
+// diffContext is the number of unchanged lines shown around the changed
+// ones by lineDiff.
+const diffContext = 3
+
+// lineDiff returns the differences between the lines of a and b.  Lines only
+// in a are prefixed with "-", lines only in b with "+", and the unchanged
+// lines around them with " ".  Unchanged lines further away are left out
+// and marked with "...".
+func lineDiff(a, b string) string {
+	al := strings.SplitAfter(a, "\n")
+	bl := strings.SplitAfter(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:]
+	// and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			switch {
+			case al[i] == bl[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	for i, j := 0, 0; i < len(al) || j < len(bl); {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			lines = append(lines, line{' ', al[i]})
+			i++
+			j++
+		case j == len(bl) || i < len(al) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, line{'-', al[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', bl[j]})
+			j++
+		}
+	}
+
+	// Show the unchanged lines which are close to a changed one.
+	show := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := i - diffContext; k <= i+diffContext; k++ {
+			if k >= 0 && k < len(lines) {
+				show[k] = true
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	skipped := false
+	for i, l := range lines {
+		if !show[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			buf.WriteString("...\n")
+			skipped = false
+		}
+		if l.text == "" {
+			continue
+		}
+		buf.WriteByte(l.op)
+		buf.WriteByte(' ')
+		buf.WriteString(l.text)
+		if !strings.HasSuffix(l.text, "\n") {
+			buf.WriteString("\n\\ no newline at end\n")
+		}
+	}
+	if skipped {
+		buf.WriteString("...\n")
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,97 @@
+package spewtest_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew/spewtest"
+)
+
+// update is an -update flag of the package under test, which must not
+// collide with the flag of Snapshot.
+var update = flag.Bool("update", false, "unused")
+
+type node struct {
+	Name     string
+	Parent   *node
+	Children []*node
+	Attrs    map[string]int
+}
+
+// tree returns a small tree whose children point back to their parent.
+func tree() *node {
+	root := &node{Name: "root", Attrs: map[string]int{"b": 2, "a": 1}}
+	root.Children = []*node{{Name: "leaf", Parent: root}}
+	return root
+}
+
+func TestSnapshot(t *testing.T) {
+	spewtest.Snapshot(t, "tree", tree())
+}
+
+func TestSnapshotMismatch(t *testing.T) {
+	v := tree()
+	v.Attrs["b"] = 3
+	v.Children[0].Name = "other"
+
+	r := &recorder{name: "TestSnapshot"}
+	if spewtest.Snapshot(r, "tree", v) {
+		t.Errorf("Snapshot mismatch reported as equal")
+	}
+	want := []string{"spewtest: snapshot testdata/TestSnapshot/tree.golden differs (-golden +got):\n" +
+		"...\n" +
+		"   Parent: (*spewtest_test.node)(<nil>),\n" +
+		"   Children: ([]*spewtest_test.node) (len=1 cap=1) {\n" +
+		"    (*spewtest_test.node)(&2)({\n" +
+		"-    Name: (string) (len=4) \"leaf\",\n" +
+		"+    Name: (string) (len=5) \"other\",\n" +
+		"     Parent: (*spewtest_test.node)(-> &1),\n" +
+		"     Children: ([]*spewtest_test.node) <nil>,\n" +
+		"     Attrs: (map[string]int) <nil>\n" +
+		"...\n" +
+		"   },\n" +
+		"   Attrs: (map[string]int) (len=2) {\n" +
+		"    (string) (len=1) \"a\": (int) 1,\n" +
+		"-   (string) (len=1) \"b\": (int) 2\n" +
+		"+   (string) (len=1) \"b\": (int) 3\n" +
+		"   }\n" +
+		"  })\n"}
+	if len(r.errors) != 1 || r.errors[0] != want[0] {
+		t.Errorf("Snapshot mismatch\n got: %q\nwant: %q", r.errors, want)
+	}
+}
+
+func TestSnapshotMissing(t *testing.T) {
+	r := &recorder{name: "TestSnapshotMissing"}
+	if spewtest.Snapshot(r, "missing", 1) || len(r.errors) != 1 {
+		t.Errorf("Snapshot of missing golden file\n got: %q", r.errors)
+	}
+}
+
+func TestSnapshotUpdate(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	flag.Set("spewtest.update", "true")
+	defer flag.Set("spewtest.update", "false")
+
+	r := &recorder{name: "TestSnapshotUpdate/sub"}
+	if !spewtest.Snapshot(r, "value", []int{1}) || len(r.errors) != 0 {
+		t.Errorf("Snapshot update failed: %q", r.errors)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "testdata", "TestSnapshotUpdate", "sub", "value.golden"))
+	want := "([]int) (len=1 cap=1) {\n (int) 1\n}\n"
+	if err != nil || string(got) != want {
+		t.Errorf("Snapshot update\n got: %q %v want: %q", got, err, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Package spewtest provides test assertions which compare values deeply with
+// spew and report their differences in a readable form on failure.
+//
+// Values are compared like reflect.DeepEqual does, except that NaN equals
+// NaN, so unexported struct fields are compared too and pointers are followed
+// rather than compared by address:
+//
+//	func TestParse(t *testing.T) {
+//		got := Parse(input)
+//		spewtest.Equal(t, want, got)
+//	}
+//
+// A failed Equal reports every path at which the values differ:
+//
+//	spewtest: values differ (want -> got):
+//	.Spec.Replicas: (int) 3 -> (int) 2
+//	.Labels["app"]: (string) "web" -> <missing>
+//
+// Snapshot compares the dump of a value to a golden file instead, which is
+// rewritten when the tests are run with the -spewtest.update flag.
+package spewtest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// Config is the configuration the values are compared and displayed with.
+// Its defaults leave out pointer addresses and capacities, which rarely
+// matter in tests, and sort map keys, so failures are reported the same on
+// every run.
+var Config = spew.ConfigState{
+	Indent:                  " ",
+	DisablePointerAddresses: true,
+	DisableCapacities:       true,
+	SortKeys:                true,
+}
+
+// Equal reports an error if got is not deeply equal to want.  The error lists
+// every path at which the values differ, see spew.Diff.  It returns whether
+// the values are equal.
+//
+// The values are compared like reflect.DeepEqual does, except that NaN equals
+// NaN.  Therefore struct fields hidden by the spew struct tag or a FieldRule
+// of Config are compared too, and times are only equal if their location and
+// monotonic clock reading are.  If the values only differ in such details,
+// both are dumped instead.
+func Equal(t testing.TB, want, got interface{}) bool {
+	t.Helper()
+
+	if equal(want, got) {
+		return true
+	}
+
+	if diff := Config.Diff(want, got); diff != "" {
+		t.Errorf("spewtest: values differ (want -> got):\n%s", diff)
+	} else {
+		t.Errorf("spewtest: values differ in details which are not displayed:\nwant: %sgot: %s",
+			Config.Sdump(want), Config.Sdump(got))
+	}
+	return false
+}
+
+// NotEqual reports an error if got is deeply equal to want, see Equal.  It
+// returns whether the values differ.
+func NotEqual(t testing.TB, want, got interface{}) bool {
+	t.Helper()
+
+	if !equal(want, got) {
+		return true
+	}
+
+	t.Errorf("spewtest: values are equal:\n%s", Config.Sdump(got))
+	return false
+}
+
+// Contains reports an error if container does not contain elem.  Strings
+// contain their substrings, arrays and slices their elements and maps their
+// keys, which are compared deeply.  It returns whether elem is contained.
+func Contains(t testing.TB, container, elem interface{}) bool {
+	t.Helper()
+
+	found, ok := contains(container, elem)
+	if !ok {
+		t.Errorf("spewtest: can not look for an element in:\n%s", Config.Sdump(container))
+		return false
+	}
+	if !found {
+		t.Errorf("spewtest: element not contained:\nelement: %scontainer: %s",
+			Config.Sdump(elem), Config.Sdump(container))
+		return false
+	}
+
+	return true
+}
+
+// contains returns whether container contains elem, see Contains.  ok is
+// false if container is of a kind which does not contain elements.
+func contains(container, elem interface{}) (found, ok bool) {
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.String:
+		s, isString := elem.(string)
+		return isString && strings.Contains(v.String(), s), true
+
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if equal(v.Index(i).Interface(), elem) {
+				return true, true
+			}
+		}
+		return false, true
+
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if equal(key.Interface(), elem) {
+				return true, true
+			}
+		}
+		return false, true
+	}
+
+	return false, false
+}
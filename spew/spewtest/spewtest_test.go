@@ -0,0 +1,158 @@
+package spewtest_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/l0nax/go-spew/spew/spewtest"
+)
+
+// recorder is a testing.TB which records the reported errors instead of
+// failing the test.
+type recorder struct {
+	testing.TB
+	name   string
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Name() string {
+	return r.name
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type item struct {
+	name  string
+	count *int
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// secret has a field which is never displayed.
+type secret struct {
+	User     string
+	Password string `spew:"-"`
+}
+
+// ring is a circular data structure.
+type ring struct {
+	N    float64
+	Next *ring
+}
+
+func newRing(n float64) *ring {
+	r := &ring{N: n}
+	r.Next = &ring{N: n, Next: r}
+	return r
+}
+
+func TestEqual(t *testing.T) {
+	nan := math.NaN()
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		want, got interface{}
+		ok        bool
+		errors    []string
+	}{
+		{1, 1, true, nil},
+		{item{"a", intPtr(1)}, item{"a", intPtr(1)}, true, nil},
+		{
+			item{"a", intPtr(1)}, item{"b", intPtr(2)}, false,
+			[]string{"spewtest: values differ (want -> got):\n" +
+				".name: (string) \"a\" -> (string) \"b\"\n" +
+				".count: (int) 1 -> (int) 2\n"},
+		},
+		{
+			map[string]int{"x": 1}, map[string]int{"y": 1}, false,
+			[]string{"spewtest: values differ (want -> got):\n" +
+				"[\"x\"]: (int) 1 -> <missing>\n" +
+				"[\"y\"]: <missing> -> (int) 1\n"},
+		},
+		{math.NaN(), math.NaN(), true, nil},
+		{map[float64]int{nan: 1, nan: 2}, map[float64]int{nan: 2, nan: 1}, true, nil},
+		{newRing(math.NaN()), newRing(math.NaN()), true, nil},
+		{
+			secret{"u", "a"}, secret{"u", "b"}, false,
+			[]string{"spewtest: values differ in details which are not displayed:\n" +
+				"want: (spewtest_test.secret) {\n User: (string) (len=1) \"u\"\n}\n" +
+				"got: (spewtest_test.secret) {\n User: (string) (len=1) \"u\"\n}\n"},
+		},
+		{
+			at, at.In(time.FixedZone("UTC", 0)), false,
+			[]string{"spewtest: values differ in details which are not displayed:\n" +
+				"want: (time.Time) 2021-03-04T05:06:07Z\n" +
+				"got: (time.Time) 2021-03-04T05:06:07Z\n"},
+		},
+	}
+
+	for i, test := range tests {
+		r := &recorder{}
+		ok := spewtest.Equal(r, test.want, test.got)
+		if ok != test.ok || fmt.Sprint(r.errors) != fmt.Sprint(test.errors) {
+			t.Errorf("Equal #%d\n got: %v %q want: %v %q", i, ok, r.errors, test.ok, test.errors)
+		}
+	}
+}
+
+func TestNotEqual(t *testing.T) {
+	tests := []struct {
+		want, got interface{}
+		ok        bool
+		errors    []string
+	}{
+		{1, 2, true, nil},
+		{item{"a", intPtr(1)}, item{"a", intPtr(2)}, true, nil},
+		{
+			[]int{1}, []int{1}, false,
+			[]string{"spewtest: values are equal:\n([]int) (len=1) {\n (int) 1\n}\n"},
+		},
+		{secret{"u", "a"}, secret{"u", "b"}, true, nil},
+	}
+
+	for i, test := range tests {
+		r := &recorder{}
+		ok := spewtest.NotEqual(r, test.want, test.got)
+		if ok != test.ok || fmt.Sprint(r.errors) != fmt.Sprint(test.errors) {
+			t.Errorf("NotEqual #%d\n got: %v %q want: %v %q", i, ok, r.errors, test.ok, test.errors)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		container, elem interface{}
+		ok              bool
+		errors          []string
+	}{
+		{"hello world", "lo w", true, nil},
+		{[]item{{"a", nil}, {"b", intPtr(1)}}, item{"b", intPtr(1)}, true, nil},
+		{map[int]string{3: "c"}, 3, true, nil},
+		{
+			[2]int{1, 2}, 3, false,
+			[]string{"spewtest: element not contained:\n" +
+				"element: (int) 3\n" +
+				"container: ([2]int) (len=2) {\n (int) 1,\n (int) 2\n}\n"},
+		},
+		{
+			42, 4, false,
+			[]string{"spewtest: can not look for an element in:\n(int) 42\n"},
+		},
+	}
+
+	for i, test := range tests {
+		r := &recorder{}
+		ok := spewtest.Contains(r, test.container, test.elem)
+		if ok != test.ok || fmt.Sprint(r.errors) != fmt.Sprint(test.errors) {
+			t.Errorf("Contains #%d\n got: %v %q want: %v %q", i, ok, r.errors, test.ok, test.errors)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package spew
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminalFd returns whether fd refers to a terminal.
+func isTerminalFd(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
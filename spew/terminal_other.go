@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package spew
+
+// isTerminalFd returns whether fd refers to a terminal.  Terminals are not
+// detected on this platform, so ColorAuto never emits colors.
+func isTerminalFd(fd uintptr) bool {
+	return false
+}
@@ -0,0 +1,300 @@
+package spew
+
+import (
+	gcolor "github.com/gookit/color"
+)
+
+// Theme maps every Type to the ColorPrinter used to colorize it.  Types
+// without an entry, or with a nil entry, are not colorized.
+//
+// The predefined themes are shared by all ConfigState instances and must not
+// be modified.  Use With to derive a custom theme instead:
+//
+//	cfg := spew.ConfigState{
+//		Indent:          " ",
+//		HighlightValues: true,
+//		Theme: spew.SolarizedTheme.With(spew.Theme{
+//			spew.TString: gcolor.HEX("#ff0000"),
+//		}),
+//	}
+type Theme map[Type]ColorPrinter
+
+// With returns a new Theme holding all entries of t, with the entries of
+// overrides replacing the ones of t.  Neither t nor overrides are modified.
+func (t Theme) With(overrides Theme) Theme {
+	derived := make(Theme, len(t)+len(overrides))
+	for typ, col := range t {
+		derived[typ] = col
+	}
+
+	for typ, col := range overrides {
+		derived[typ] = col
+	}
+
+	return derived
+}
+
+// pre-defined colors
+var (
+	cBlue          = gcolor.HEX("#82aaff", false)
+	cPurple        = gcolor.HEX("#c792ea", false)
+	cOrange        = gcolor.HEX("#f78c6c", false)
+	cSpecial       = gcolor.HEXStyle("#ffffff", "#c17e70")
+	cGreen         = gcolor.HEX("#c3e88d", false)
+	cDarkGreen     = gcolor.HEX("#138040", false)
+	cRadiantYellow = gcolor.HEX("#ffea00", false)
+	cCyan          = gcolor.HEX("#89ddff", false)
+)
+
+// DefaultTheme is the theme used when ConfigState.Theme is not set.  It is
+// designed for terminals with a dark background.
+var DefaultTheme = Theme{
+	TInteger: cBlue,
+	TFloat:   cBlue,
+	TMap:     gcolor.LightRed.RGB(),
+	TDate:    gcolor.Green.RGB(),
+	TString:  gcolor.LightYellow.RGB(),
+	TBool:    gcolor.LightBlue.RGB(),
+	TNil:     gcolor.LightMagenta.RGB(),
+	TArray:   gcolor.LightWhite.RGB(),
+	TStruct:  gcolor.Yellow.RGB(),
+
+	TTInteger:   gcolor.Cyan.RGB(),
+	TTFloat:     gcolor.Cyan.RGB(),
+	TTMap:       gcolor.LightRed.RGB(),
+	TTDate:      gcolor.Green.RGB(),
+	TTString:    cOrange,
+	TTBool:      cPurple,
+	TTNil:       gcolor.LightMagenta.RGB(),
+	TTArray:     cGreen,
+	TTPtr:       gcolor.Magenta.RGB(),
+	TTAddress:   cDarkGreen,
+	TTInterface: cRadiantYellow,
+
+	TLen:       cSpecial,
+	TCap:       cSpecial,
+	TArgs:      cGreen,
+	TCircular:  gcolor.LightMagenta.RGB(),
+	TMaxDepth:  gcolor.LightMagenta.RGB(),
+	TFieldName: cCyan,
+	TRedacted:  gcolor.LightRed.RGB(),
+	TTruncated: gcolor.LightMagenta.RGB(),
+	TPath:      gcolor.Gray.RGB(),
+
+	TNonPrintable:    gcolor.Red.RGB(),
+	TPrintable:       cOrange,
+	TBase10:          cRadiantYellow,
+	TWhitespaceChar:  cDarkGreen,
+	TPunctuationChar: cPurple,
+	TNULByte:         gcolor.Gray.RGB(),
+
+	TDiffRemoved: gcolor.HEX("#ff5370", false),
+	TDiffAdded:   cGreen,
+}
+
+// LightTheme is designed for terminals with a light background.
+var LightTheme = Theme{
+	TInteger: gcolor.HEX("#005cc5"),
+	TFloat:   gcolor.HEX("#005cc5"),
+	TMap:     gcolor.HEX("#b31d28"),
+	TDate:    gcolor.HEX("#22863a"),
+	TString:  gcolor.HEX("#032f62"),
+	TBool:    gcolor.HEX("#6f42c1"),
+	TNil:     gcolor.HEX("#d73a49"),
+	TArray:   gcolor.HEX("#24292e"),
+	TStruct:  gcolor.HEX("#735c0f"),
+
+	TTInteger:   gcolor.HEX("#1b7c83"),
+	TTFloat:     gcolor.HEX("#1b7c83"),
+	TTMap:       gcolor.HEX("#b31d28"),
+	TTDate:      gcolor.HEX("#22863a"),
+	TTString:    gcolor.HEX("#e36209"),
+	TTBool:      gcolor.HEX("#6f42c1"),
+	TTNil:       gcolor.HEX("#d73a49"),
+	TTArray:     gcolor.HEX("#22863a"),
+	TTPtr:       gcolor.HEX("#8a1e8f"),
+	TTAddress:   gcolor.HEX("#6a737d"),
+	TTInterface: gcolor.HEX("#b08800"),
+
+	TLen:       gcolor.HEXStyle("#ffffff", "#6a737d"),
+	TCap:       gcolor.HEXStyle("#ffffff", "#6a737d"),
+	TArgs:      gcolor.HEX("#22863a"),
+	TCircular:  gcolor.HEX("#d73a49"),
+	TMaxDepth:  gcolor.HEX("#d73a49"),
+	TFieldName: gcolor.HEX("#953800"),
+	TRedacted:  gcolor.HEX("#cb2431"),
+	TTruncated: gcolor.HEX("#d73a49"),
+	TPath:      gcolor.HEX("#6a737d"),
+
+	TNonPrintable:    gcolor.HEX("#d73a49"),
+	TPrintable:       gcolor.HEX("#e36209"),
+	TBase10:          gcolor.HEX("#b08800"),
+	TWhitespaceChar:  gcolor.HEX("#22863a"),
+	TPunctuationChar: gcolor.HEX("#6f42c1"),
+	TNULByte:         gcolor.HEX("#959da5"),
+
+	TDiffRemoved: gcolor.HEX("#b31d28"),
+	TDiffAdded:   gcolor.HEX("#22863a"),
+}
+
+// solarized color scheme, see https://ethanschoonover.com/solarized/
+var (
+	cSolBase01  = gcolor.HEX("#586e75")
+	cSolBase1   = gcolor.HEX("#93a1a1")
+	cSolYellow  = gcolor.HEX("#b58900")
+	cSolOrange  = gcolor.HEX("#cb4b16")
+	cSolRed     = gcolor.HEX("#dc322f")
+	cSolMagenta = gcolor.HEX("#d33682")
+	cSolViolet  = gcolor.HEX("#6c71c4")
+	cSolBlue    = gcolor.HEX("#268bd2")
+	cSolCyan    = gcolor.HEX("#2aa198")
+	cSolGreen   = gcolor.HEX("#859900")
+)
+
+// SolarizedTheme uses the accent colors of the Solarized color scheme and
+// works with both its light and its dark variant.
+var SolarizedTheme = Theme{
+	TInteger: cSolBlue,
+	TFloat:   cSolBlue,
+	TMap:     cSolRed,
+	TDate:    cSolGreen,
+	TString:  cSolCyan,
+	TBool:    cSolViolet,
+	TNil:     cSolMagenta,
+	TArray:   cSolBase1,
+	TStruct:  cSolYellow,
+
+	TTInteger:   cSolYellow,
+	TTFloat:     cSolYellow,
+	TTMap:       cSolRed,
+	TTDate:      cSolGreen,
+	TTString:    cSolOrange,
+	TTBool:      cSolViolet,
+	TTNil:       cSolMagenta,
+	TTArray:     cSolGreen,
+	TTPtr:       cSolMagenta,
+	TTAddress:   cSolBase01,
+	TTInterface: cSolYellow,
+
+	TLen:       gcolor.HEXStyle("#fdf6e3", "#586e75"),
+	TCap:       gcolor.HEXStyle("#fdf6e3", "#586e75"),
+	TArgs:      cSolGreen,
+	TCircular:  cSolMagenta,
+	TMaxDepth:  cSolMagenta,
+	TFieldName: cSolBase1,
+	TRedacted:  cSolRed,
+	TTruncated: cSolMagenta,
+	TPath:      cSolBase01,
+
+	TNonPrintable:    cSolRed,
+	TPrintable:       cSolOrange,
+	TBase10:          cSolYellow,
+	TWhitespaceChar:  cSolGreen,
+	TPunctuationChar: cSolViolet,
+	TNULByte:         cSolBase01,
+
+	TDiffRemoved: cSolRed,
+	TDiffAdded:   cSolGreen,
+}
+
+// text attributes used by MonochromeBoldTheme
+var (
+	cBold       = gcolor.Style{gcolor.OpBold}
+	cItalic     = gcolor.Style{gcolor.OpItalic}
+	cUnderscore = gcolor.Style{gcolor.OpUnderscore}
+	cReverse    = gcolor.Style{gcolor.OpReverse}
+	cFuzzy      = gcolor.Style{gcolor.OpFuzzy}
+)
+
+// MonochromeBoldTheme does not use any colors at all but only text attributes
+// such as bold, italic and underline.  It is useful for monochrome terminals
+// and for users who have difficulties distinguishing colors.
+var MonochromeBoldTheme = Theme{
+	TInteger: cBold,
+	TFloat:   cBold,
+	TMap:     cBold,
+	TDate:    cBold,
+	TString:  cBold,
+	TBool:    cBold,
+	TNil:     cItalic,
+	TArray:   cBold,
+	TStruct:  cBold,
+
+	TTInteger:   cUnderscore,
+	TTFloat:     cUnderscore,
+	TTMap:       cUnderscore,
+	TTDate:      cUnderscore,
+	TTString:    cUnderscore,
+	TTBool:      cUnderscore,
+	TTNil:       cUnderscore,
+	TTArray:     cUnderscore,
+	TTPtr:       cUnderscore,
+	TTAddress:   cFuzzy,
+	TTInterface: cUnderscore,
+
+	TLen:       cReverse,
+	TCap:       cReverse,
+	TArgs:      cItalic,
+	TCircular:  cItalic,
+	TMaxDepth:  cItalic,
+	TFieldName: gcolor.Style{gcolor.OpBold, gcolor.OpItalic},
+	TRedacted:  cReverse,
+	TTruncated: cItalic,
+	TPath:      cFuzzy,
+
+	TNonPrintable:    cFuzzy,
+	TPrintable:       cBold,
+	TBase10:          cBold,
+	TWhitespaceChar:  cUnderscore,
+	TPunctuationChar: cItalic,
+	TNULByte:         cFuzzy,
+
+	TDiffRemoved: gcolor.Style{gcolor.OpStrikethrough},
+	TDiffAdded:   cBold,
+}
+
+// HighContrastTheme only uses bold, bright basic colors on the terminal's
+// default background to maximize legibility.
+var HighContrastTheme = Theme{
+	TInteger: gcolor.Style{gcolor.FgLightCyan, gcolor.OpBold},
+	TFloat:   gcolor.Style{gcolor.FgLightCyan, gcolor.OpBold},
+	TMap:     gcolor.Style{gcolor.FgLightRed, gcolor.OpBold},
+	TDate:    gcolor.Style{gcolor.FgLightGreen, gcolor.OpBold},
+	TString:  gcolor.Style{gcolor.FgLightYellow, gcolor.OpBold},
+	TBool:    gcolor.Style{gcolor.FgLightBlue, gcolor.OpBold},
+	TNil:     gcolor.Style{gcolor.FgLightMagenta, gcolor.OpBold},
+	TArray:   gcolor.Style{gcolor.FgLightWhite, gcolor.OpBold},
+	TStruct:  gcolor.Style{gcolor.FgLightYellow, gcolor.OpBold},
+
+	TTInteger:   gcolor.Style{gcolor.FgLightGreen},
+	TTFloat:     gcolor.Style{gcolor.FgLightGreen},
+	TTMap:       gcolor.Style{gcolor.FgLightRed},
+	TTDate:      gcolor.Style{gcolor.FgLightGreen},
+	TTString:    gcolor.Style{gcolor.FgLightGreen},
+	TTBool:      gcolor.Style{gcolor.FgLightGreen},
+	TTNil:       gcolor.Style{gcolor.FgLightMagenta},
+	TTArray:     gcolor.Style{gcolor.FgLightGreen},
+	TTPtr:       gcolor.Style{gcolor.FgLightMagenta},
+	TTAddress:   gcolor.Style{gcolor.FgLightWhite},
+	TTInterface: gcolor.Style{gcolor.FgLightYellow},
+
+	TLen:       gcolor.Style{gcolor.FgBlack, gcolor.BgLightWhite},
+	TCap:       gcolor.Style{gcolor.FgBlack, gcolor.BgLightWhite},
+	TArgs:      gcolor.Style{gcolor.FgLightGreen},
+	TCircular:  gcolor.Style{gcolor.FgBlack, gcolor.BgLightMagenta},
+	TMaxDepth:  gcolor.Style{gcolor.FgBlack, gcolor.BgLightMagenta},
+	TFieldName: gcolor.Style{gcolor.FgLightWhite},
+	TRedacted:  gcolor.Style{gcolor.FgBlack, gcolor.BgLightRed},
+	TTruncated: gcolor.Style{gcolor.FgBlack, gcolor.BgLightMagenta},
+	TPath:      gcolor.Style{gcolor.FgLightWhite, gcolor.OpItalic},
+
+	TNonPrintable:    gcolor.Style{gcolor.FgLightRed, gcolor.OpBold},
+	TPrintable:       gcolor.Style{gcolor.FgLightWhite, gcolor.OpBold},
+	TBase10:          gcolor.Style{gcolor.FgLightYellow, gcolor.OpBold},
+	TWhitespaceChar:  gcolor.Style{gcolor.FgLightGreen, gcolor.OpBold},
+	TPunctuationChar: gcolor.Style{gcolor.FgLightCyan, gcolor.OpBold},
+	TNULByte:         gcolor.Style{gcolor.FgDarkGray},
+
+	TDiffRemoved: gcolor.Style{gcolor.FgLightRed, gcolor.OpBold},
+	TDiffAdded:   gcolor.Style{gcolor.FgLightGreen, gcolor.OpBold},
+}
@@ -0,0 +1,96 @@
+package spew_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// allTypes holds every color type defined by the spew package.
+var allTypes = []spew.Type{
+	spew.TInteger, spew.TFloat, spew.TMap, spew.TDate, spew.TString,
+	spew.TBool, spew.TNil, spew.TArray, spew.TStruct,
+
+	spew.TTInteger, spew.TTFloat, spew.TTMap, spew.TTDate, spew.TTString,
+	spew.TTBool, spew.TTNil, spew.TTArray, spew.TTPtr, spew.TTAddress,
+	spew.TTInterface,
+
+	spew.TLen, spew.TCap, spew.TArgs, spew.TCircular, spew.TMaxDepth,
+	spew.TFieldName, spew.TRedacted, spew.TTruncated, spew.TPath,
+
+	spew.TNonPrintable, spew.TPrintable, spew.TBase10, spew.TWhitespaceChar,
+	spew.TPunctuationChar, spew.TNULByte,
+
+	spew.TDiffRemoved, spew.TDiffAdded,
+}
+
+// tagPrinter is a ColorPrinter which wraps the printed text in a tag instead
+// of ANSI escape codes, so colorized output can be tested independently of
+// the terminal.
+type tagPrinter string
+
+func (p tagPrinter) Sprint(a ...interface{}) string {
+	return "<" + string(p) + ">" + fmt.Sprint(a...) + "</" + string(p) + ">"
+}
+
+// TestThemesComplete ensures the predefined themes define a color for every
+// color type.
+func TestThemesComplete(t *testing.T) {
+	themes := map[string]spew.Theme{
+		"DefaultTheme":        spew.DefaultTheme,
+		"LightTheme":          spew.LightTheme,
+		"SolarizedTheme":      spew.SolarizedTheme,
+		"MonochromeBoldTheme": spew.MonochromeBoldTheme,
+		"HighContrastTheme":   spew.HighContrastTheme,
+	}
+
+	for name, theme := range themes {
+		for _, typ := range allTypes {
+			if theme[typ] == nil {
+				t.Errorf("%s has no color for type %d", name, typ)
+			}
+		}
+	}
+}
+
+// TestThemeWith ensures With derives a new theme without modifying the
+// original one and that the derived theme is used by the ConfigState.
+func TestThemeWith(t *testing.T) {
+	base := spew.Theme{
+		spew.TInteger:  tagPrinter("int"),
+		spew.TTInteger: tagPrinter("tint"),
+	}
+	derived := base.With(spew.Theme{
+		spew.TInteger: tagPrinter("num"),
+		spew.TString:  tagPrinter("str"),
+	})
+
+	if base[spew.TInteger] != tagPrinter("int") || base[spew.TString] != nil {
+		t.Errorf("With modified the original theme: %v", base)
+	}
+
+	tests := []struct {
+		theme spew.Theme
+		in    interface{}
+		want  string
+	}{
+		{base, 5, "(<tint>int</tint>) <int>5</int>\n"},
+		{base, "a", "(string) (len=1) \"a\"\n"},
+		{derived, 5, "(<tint>int</tint>) <num>5</num>\n"},
+		{derived, "a", "(string) (len=1) <str>\"a\"</str>\n"},
+		{nil, 5, "(int) 5\n"},
+	}
+
+	for i, test := range tests {
+		cs := spew.ConfigState{
+			HighlightValues: test.theme != nil,
+			ColorMode:       spew.ColorAlways,
+			Theme:           test.theme,
+		}
+		s := cs.Sdump(test.in)
+		if s != test.want {
+			t.Errorf("ThemeWith #%d\n got: %s want: %s", i, s, test.want)
+		}
+	}
+}
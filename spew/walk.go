@@ -0,0 +1,692 @@
+package spew
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// partKind is the kind of a part of a value passed to the renderer while the
+// value is walked.
+type partKind int
+
+const (
+	// partElem is an element of an array or slice, or one passed to
+	// Printer.Elem.
+	partElem partKind = iota
+
+	// partKey is the key of a map entry.  It is followed by a partValue
+	// holding the value of the entry.
+	partKey
+
+	// partValue is the value of a map entry.
+	partValue
+
+	// partField is a struct field, or one passed to Printer.Field.
+	partField
+
+	// partInline is a value passed to Printer.Value.
+	partInline
+
+	// partText is text written by Printer.Write or Printer.Text.
+	partText
+
+	// partOpen opens the block of a custom formatter.
+	partOpen
+
+	// partClose closes the block of a custom formatter.
+	partClose
+)
+
+// part is a part of a value passed to the renderer while the value is walked.
+type part struct {
+	kind partKind
+
+	// node is the element, entry key or value, or field.
+	node *Node
+
+	// index is the position of node among the children of its parent, and
+	// last specifies whether it is the last one, which decide about the
+	// separators around it.  They are not set for the children of custom
+	// formatters.
+	index int
+	last  bool
+
+	// text is the text of a partText, which is written in the color of
+	// typ if colored is set.
+	text    string
+	typ     Type
+	colored bool
+
+	// elems specifies whether the block opened by a partOpen holds
+	// elements rather than fields.
+	elems bool
+}
+
+// methodResult is the outcome of calling the Error or String method of a
+// value.
+type methodResult struct {
+	str      string
+	panicked bool
+	panicStr string
+}
+
+// write writes r like handleMethods does and returns whether the value is
+// displayed completely by it.
+func (r *methodResult) write(cs *ConfigState, w io.Writer) (handled bool) {
+	if cs.ContinueOnMethod {
+		w.Write(openParenBytes)
+	}
+
+	if r.panicked {
+		w.Write(panicBytes)
+		w.Write([]byte(r.panicStr))
+		w.Write(closeParenBytes)
+		return false
+	}
+
+	w.Write([]byte(r.str))
+	if cs.ContinueOnMethod {
+		w.Write(closeParenBytes)
+		w.Write(spaceBytes)
+		return false
+	}
+
+	return true
+}
+
+// callMethod calls the Error or String method of v, which must be a value
+// returned by methodReceiver.  The returned bool is false if v implements
+// neither error nor fmt.Stringer.
+func callMethod(v reflect.Value) (r *methodResult, ok bool) {
+	var fn func() string
+	switch iface := v.Interface().(type) {
+	case error:
+		fn = iface.Error
+	case fmt.Stringer:
+		fn = iface.String
+	default:
+		return nil, false
+	}
+
+	r, ok = new(methodResult), true
+	defer func() {
+		if err := recover(); err != nil {
+			r.panicked = true
+			r.panicStr = fmt.Sprintf("%v", err)
+		}
+	}()
+	r.str = fn()
+
+	return r, ok
+}
+
+// walkState contains information about the state of a walk over a value.  It
+// performs the traversal shared by Dump and the custom Formatter, such as
+// following pointers, detecting circular references, calling methods and
+// filtering fields, and hands the resulting Nodes to the renderer.
+//
+// Children are walked lazily, one at a time, while the renderer displays the
+// value, so the walk stops as soon as the renderer does.
+type walkState struct {
+	cs       *ConfigState
+	depth    int
+	pointers map[uintptr]int
+
+	// compact specifies whether the value is walked for the custom
+	// Formatter, which prefers SpewFormat to SpewDump and does not hex dump
+	// byte slices.
+	compact bool
+
+	// path is the path of the value currently walked.  It is only tracked
+	// if trackPaths is set, see ConfigState.tracksPaths.
+	path       string
+	trackPaths bool
+
+	// expr is the Go expression of the value currently walked.  It is only
+	// tracked if AnnotatePaths is set.
+	expr goExpr
+
+	// ids maps pointer targets to their ordinals if PointerIDs is set.
+	ids map[uintptr]int
+
+	// shared maps pointer targets to the paths of their first appearance if
+	// DetectSharedPointers is set.
+	shared map[uintptr]string
+
+	// ctx is the context of the walk, if any.  Methods which do not return
+	// before it is done are abandoned.
+	ctx context.Context
+
+	// more is called before each element, map entry and struct field and
+	// returns whether the walk continues, if set.
+	more func() bool
+}
+
+// newWalkState returns a walkState for a single value.
+func newWalkState(ctx context.Context, cs *ConfigState, compact bool) *walkState {
+	w := &walkState{cs: cs, ctx: ctx, compact: compact, trackPaths: cs.tracksPaths()}
+	w.pointers = make(map[uintptr]int)
+	if cs.PointerIDs {
+		w.ids = make(map[uintptr]int)
+	}
+	if cs.sharesPaths() {
+		w.shared = make(map[uintptr]string)
+	}
+	if cs.AnnotatePaths && !compact {
+		w.expr.expr = cs.PathRoot
+		if w.expr.expr == "" {
+			w.expr.expr = "v"
+		}
+	}
+
+	return w
+}
+
+// proceed returns whether the walk continues with the next child.
+func (w *walkState) proceed() bool {
+	return w.more == nil || w.more()
+}
+
+// child returns the node of v, which is a child of the value currently
+// walked.  Values inside of non-nil interfaces are unpacked.
+func (w *walkState) child(v reflect.Value) *Node {
+	val := v
+	iface := v.Kind() == reflect.Interface
+	if iface && !v.IsNil() {
+		v = v.Elem()
+		expr := w.expr
+		defer func() { w.expr = expr }()
+		w.expr = expr.assert(v.Type())
+	}
+
+	n := w.node(v)
+	n.iface, n.val = iface, val
+	return n
+}
+
+// node returns the node of v.  Its children are not walked yet, see
+// children.
+func (w *walkState) node(v reflect.Value) *Node {
+	kind := v.Kind()
+	if kind == reflect.Invalid {
+		return &Node{Invalid: true}
+	}
+
+	if kind == reflect.Ptr {
+		n := w.ptrNode(v)
+		n.val = v
+		return n
+	}
+
+	n := &Node{Type: v.Type().String(), Kind: kind, typ: v.Type(), val: v, base: w.expr}
+	w.fill(n, v)
+	return n
+}
+
+// ptrNode returns the node of the pointer v.  It describes the value at the
+// end of the chain of pointers, unless the chain ends at a nil pointer or a
+// value which is already shown.
+func (w *walkState) ptrNode(v reflect.Value) *Node {
+	// Remove pointers at or below the current depth from map used to detect
+	// circular refs.
+	for k, depth := range w.pointers {
+		if depth >= w.depth {
+			delete(w.pointers, k)
+		}
+	}
+
+	// Figure out how many levels of indirection there are by dereferencing
+	// pointers and unpacking interfaces down the chain while detecting circular
+	// references.
+	n := new(Node)
+	ve := v
+	expr := w.expr
+	for ve.Kind() == reflect.Ptr {
+		if ve.IsNil() {
+			n.Nil = true
+			break
+		}
+		n.Indirects++
+		addr := ve.Pointer()
+		if w.ids != nil {
+			if id, ok := w.ids[addr]; ok {
+				n.Ref = pointerIDString(id)
+				n.Indirects--
+				break
+			}
+			w.ids[addr] = len(w.ids) + 1
+			n.Addrs = append(n.Addrs, pointerIDString(w.ids[addr]))
+		} else {
+			n.Addrs = append(n.Addrs, hexPtrText(addr))
+		}
+		if w.shared != nil {
+			if path, ok := w.shared[addr]; ok {
+				if path == "" {
+					path = "."
+				}
+				n.Ref = path
+				n.Indirects--
+				break
+			}
+			w.shared[addr] = w.path
+		}
+		if pd, ok := w.pointers[addr]; ok && pd < w.depth {
+			n.Circular = true
+			n.Indirects--
+			break
+		}
+		w.pointers[addr] = w.depth
+
+		ve = ve.Elem()
+		expr = expr.deref()
+		if ve.Kind() == reflect.Interface {
+			if ve.IsNil() {
+				n.Nil = true
+				break
+			}
+			ve = ve.Elem()
+			expr = expr.assert(ve.Type())
+		}
+	}
+
+	n.Type = strings.Repeat("*", n.Indirects) + ve.Type().String()
+	n.Kind = ve.Kind()
+	n.typ = ve.Type()
+	n.base = expr
+	if !n.Nil && !n.Circular && n.Ref == "" {
+		w.fill(n, ve)
+	}
+
+	return n
+}
+
+// fill records the value of v, which must not be a pointer, in n.
+func (w *walkState) fill(n *Node, v reflect.Value) {
+	kind := v.Kind()
+
+	// Record length and capacity if the built-in len and cap functions
+	// work with the value's kind.
+	switch kind {
+	case reflect.Array, reflect.Slice, reflect.Chan:
+		n.Len, n.Cap = v.Len(), v.Cap()
+	case reflect.Map, reflect.String:
+		n.Len = v.Len()
+	}
+
+	// Use the custom formatter registered for the type, if any.
+	if fn := w.cs.formatterFor(v.Type()); fn != nil {
+		n.custom, n.v = fn, v
+		return
+	}
+
+	// Record time values in a human readable form rather than their
+	// internal representation.
+	if str, ok := timeString(v); ok {
+		n.Value, n.time = str, true
+		return
+	}
+
+	// Call SpewDumper/SpewFormatter/Stringer/error interfaces if they
+	// exist and the handle methods flag is enabled.
+	if !w.cs.DisableMethods && kind != reflect.Interface {
+		if fn, rv, ok := spewMethod(w.cs, v, w.compact); ok {
+			n.custom, n.v = fn, rv
+			return
+		}
+
+		if w.callMethods(n, v) {
+			return
+		}
+	}
+
+	switch kind {
+	case reflect.Bool:
+		n.Value = strconv.FormatBool(v.Bool())
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		n.Value = strconv.FormatInt(v.Int(), 10)
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		n.Value = strconv.FormatUint(v.Uint(), 10)
+
+	case reflect.Float32:
+		n.Value = strconv.FormatFloat(v.Float(), 'g', -1, 32)
+
+	case reflect.Float64:
+		n.Value = strconv.FormatFloat(v.Float(), 'g', -1, 64)
+
+	case reflect.Complex64, reflect.Complex128:
+		var buf bytes.Buffer
+		printComplex(&buf, v.Complex(), v.Type().Bits()/2)
+		n.Value = buf.String()
+
+	case reflect.Slice:
+		if v.IsNil() {
+			n.Nil = true
+			break
+		}
+		fallthrough
+
+	case reflect.Array:
+		n.v = v
+		if !w.compact {
+			if buf, ok := hexDumpBytes(v); ok {
+				n.Bytes = buf
+			}
+		}
+
+	case reflect.String:
+		n.Value, n.More = truncateString(v.String(), w.cs.MaxStringLen)
+
+	case reflect.Interface:
+		// The only time we should get here is for nil interfaces due to
+		// unpacking.
+		n.Nil = v.IsNil()
+
+	case reflect.Map:
+		// nil maps should be indicated as different than empty maps
+		if v.IsNil() {
+			n.Nil = true
+			break
+		}
+		n.v = v
+
+	case reflect.Struct:
+		n.v = v
+
+	case reflect.Uintptr:
+		n.Value = hexPtrText(uintptr(v.Uint()))
+
+	case reflect.UnsafePointer, reflect.Chan, reflect.Func:
+		n.Nil = v.IsNil()
+		n.Value = hexPtrText(v.Pointer())
+
+	// There were not any other types at the time this code was written, but
+	// fall back to letting the default fmt package handle it in case any new
+	// types are added.
+	default:
+		if v.CanInterface() {
+			n.Value = fmt.Sprintf("%v", v.Interface())
+		} else {
+			n.Value = fmt.Sprintf("%v", v.String())
+		}
+	}
+}
+
+// callMethods calls the Error or String method of v, if any, and records the
+// result in n.  It returns whether the value is displayed completely by it.
+// Once the context of the walk is done, the method is abandoned and the
+// error of the context is recorded instead.  The abandoned method keeps
+// running in the background, but its result is discarded.
+func (w *walkState) callMethods(n *Node, v reflect.Value) (handled bool) {
+	rv, ok := methodReceiver(w.cs, v)
+	if !ok || !hasMethods(rv) {
+		return false
+	}
+
+	var r *methodResult
+	if w.ctx == nil || w.ctx.Done() == nil {
+		r, _ = callMethod(rv)
+	} else {
+		if err := w.ctx.Err(); err != nil {
+			n.err = err
+			return true
+		}
+
+		done := make(chan *methodResult, 1)
+		go func() {
+			r, _ := callMethod(rv)
+			done <- r
+		}()
+
+		select {
+		case r = <-done:
+		case <-w.ctx.Done():
+			n.err = w.ctx.Err()
+			return true
+		}
+	}
+
+	n.call = r
+	n.Method, n.Panic = r.str, r.panicStr
+	return !r.panicked && !w.cs.ContinueOnMethod
+}
+
+// hasMethods returns whether v, which must be a value returned by
+// methodReceiver, implements error or fmt.Stringer.
+func hasMethods(v reflect.Value) bool {
+	switch v.Interface().(type) {
+	case error, fmt.Stringer:
+		return true
+	}
+
+	return false
+}
+
+// children walks the children of n, which must be created by w, and passes
+// them to fn one by one.  The walk stops once fn returns false.
+//
+// The elements of arrays and slices, the entries of maps and the fields of
+// structs are one level deeper than n, so they are omitted and MaxDepth is
+// set on n if the maximum depth is reached.  Custom formatters open a level
+// themselves, see Printer.
+func (w *walkState) children(n *Node, fn func(p part) bool) {
+	if !n.v.IsValid() {
+		return
+	}
+
+	v := n.v
+	expr := w.expr
+	defer func() { w.expr = expr }()
+
+	// The values rendered by custom formatters are not accessed by a known
+	// expression.
+	if n.custom != nil {
+		w.expr = goExpr{}
+		p := &Printer{w: w, n: n, emit: fn}
+		p.run(n.custom, v)
+		return
+	}
+
+	w.depth++
+	defer func() { w.depth-- }()
+	if w.cs.MaxDepth != 0 && w.depth > w.cs.MaxDepth {
+		n.MaxDepth = true
+		return
+	}
+
+	path := w.path
+	defer func() { w.path = path }()
+
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		// Hex dumped bytes have no children of their own.
+		if n.Bytes != nil {
+			return
+		}
+
+		numEntries := v.Len()
+		shown := w.cs.shownElements(numEntries)
+		n.More = numEntries - shown
+		for i := 0; i < shown && w.proceed(); i++ {
+			if w.trackPaths {
+				w.path = indexPath(path, i)
+			}
+			w.expr = n.base.index(v.Kind(), i)
+			c := w.child(v.Index(i))
+			c.expr = w.expr.String()
+			if !fn(part{kind: partElem, node: c, index: i, last: i == numEntries-1}) {
+				return
+			}
+		}
+
+	case reflect.Map:
+		numEntries := v.Len()
+		keys := v.MapKeys()
+		if w.cs.SortKeys {
+			sortValues(keys, w.cs)
+		}
+		values := mapValues(v, keys)
+		shown := w.cs.shownElements(numEntries)
+		n.More = numEntries - shown
+		for i, key := range keys[:shown] {
+			if !w.proceed() {
+				return
+			}
+			if w.trackPaths {
+				w.path = keyPath(w.cs, path, key)
+			}
+			w.expr = goExpr{}
+			k := w.child(key)
+			if !fn(part{kind: partKey, node: k, index: i}) {
+				return
+			}
+			w.expr = n.base.key(w.cs, key)
+			c := w.child(values[i])
+			c.Key = k
+			c.expr = w.expr.String()
+			if !fn(part{kind: partValue, node: c, index: i, last: i == numEntries-1}) {
+				return
+			}
+		}
+
+	case reflect.Struct:
+		vt := v.Type()
+		fields := w.cs.shownFields(v, path)
+		for i, field := range fields {
+			if !w.proceed() {
+				return
+			}
+			name := vt.Field(field.index).Name
+			w.expr = n.base.field(name)
+			c := &Node{Name: name, Redacted: true, val: v.Field(field.index)}
+			if !field.redact {
+				w.path = field.path
+				c = w.child(v.Field(field.index))
+				c.Name = name
+			}
+			c.expr = w.expr.String()
+			if !fn(part{kind: partField, node: c, index: i, last: i == len(fields)-1}) {
+				return
+			}
+		}
+	}
+}
+
+// mapValues returns the values of the entries of the map v with the passed
+// keys.  Keys which are not equal to themselves, such as NaN, can not be
+// looked up, so their values are taken in the order the map is iterated.
+func mapValues(v reflect.Value, keys []reflect.Value) []reflect.Value {
+	values := make([]reflect.Value, len(keys))
+	missing := false
+	for i, key := range keys {
+		values[i] = v.MapIndex(key)
+		missing = missing || !values[i].IsValid()
+	}
+	if !missing {
+		return values
+	}
+
+	var unequal []reflect.Value
+	for iter := v.MapRange(); iter.Next(); {
+		if !v.MapIndex(iter.Key()).IsValid() {
+			unequal = append(unequal, iter.Value())
+		}
+	}
+	for i := range values {
+		if !values[i].IsValid() && len(unequal) > 0 {
+			values[i], unequal = unequal[0], unequal[1:]
+		}
+	}
+
+	return values
+}
+
+// expand walks all children of n and their descendants into the Children of
+// their parents.
+func (w *walkState) expand(n *Node) {
+	w.children(n, func(p part) bool {
+		switch p.kind {
+		case partText:
+			n.Value += p.text
+
+		case partKey:
+			w.expand(p.node)
+
+		case partElem, partValue, partField, partInline:
+			w.expand(p.node)
+			n.Children = append(n.Children, p.node)
+		}
+		return true
+	})
+}
+
+// rawConfig returns a copy of c for walking values completely and as they
+// are, which Diff and Sliteral need.  Limits, methods, custom formatters and
+// references to pointers which are already shown are disabled.
+func (c *ConfigState) rawConfig() *ConfigState {
+	raw := *c
+	raw.MaxDepth, raw.MaxElements, raw.MaxStringLen, raw.MaxBytes = 0, 0, 0, 0
+	raw.DisableMethods = true
+	raw.PointerIDs, raw.DetectSharedPointers, raw.AnnotatePaths = false, false, false
+	raw.formatters = nil
+	return &raw
+}
+
+// walkRaw returns the tree of v, including all descendants, walked with the
+// rawConfig of cs.  Like for the children of values, v is unpacked if it is
+// a non-nil interface.
+func walkRaw(cs *ConfigState, v reflect.Value) *Node {
+	w := newWalkState(nil, cs.rawConfig(), false)
+	n := w.child(v)
+	w.expand(n)
+	return n
+}
+
+// isPtr returns whether n is the node of a pointer.
+func (n *Node) isPtr() bool {
+	return n.Indirects > 0 || n.Kind == reflect.Ptr
+}
+
+// pointerIDString returns the ordinal id of a pointer target, e.g. &1.
+func pointerIDString(id int) string {
+	return string(ampersandBytes) + strconv.Itoa(id)
+}
+
+// hexPtrText returns p formatted like printHexPtr does.
+func hexPtrText(p uintptr) string {
+	var buf bytes.Buffer
+	printHexPtr(&buf, p)
+	return buf.String()
+}
+
+// Walk returns the tree of Nodes describing v as displayed by Dump with the
+// configuration cs, or Config if cs is nil.  Pointers are followed,
+// circular references detected and methods called exactly like Dump does, so
+// new output formats only need to render the tree.
+//
+// Unlike Dump, the tree holds all pointer addresses regardless of
+// DisablePointerAddresses, and all bytes of hex dumped arrays and slices
+// regardless of MaxBytes.  The text written by custom formatters and
+// SpewDumper implementations with Printer.Write and Printer.Text is
+// collected in Value, values passed to Printer.Value, Field and Elem become
+// Children.
+func Walk(cs *ConfigState, v interface{}) *Node {
+	if cs == nil {
+		cs = &Config
+	}
+
+	if v == nil {
+		return &Node{Type: "interface {}", Kind: reflect.Interface, Nil: true}
+	}
+
+	w := newWalkState(context.Background(), cs, false)
+	n := w.node(reflect.ValueOf(v))
+	w.expand(n)
+	return n
+}
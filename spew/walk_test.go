@@ -0,0 +1,122 @@
+package spew_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/l0nax/go-spew/spew"
+)
+
+// walkErr is an error with a value receiver.
+type walkErr string
+
+func (e walkErr) Error() string { return string(e) }
+
+// walkTester is walked by TestWalk.
+type walkTester struct {
+	Name  string
+	Count *int
+	Tags  map[string]int
+	Data  []byte
+	Any   interface{}
+	Err   error
+	Next  *walkTester
+}
+
+// walkPoint is rendered by a custom formatter in TestWalkCustom.
+type walkPoint struct {
+	X, Y int
+}
+
+// exported returns a copy of n which only holds its exported members, so it
+// can be compared with reflect.DeepEqual.
+func exported(n *spew.Node) *spew.Node {
+	if n == nil {
+		return nil
+	}
+
+	c := &spew.Node{Type: n.Type, Kind: n.Kind, Indirects: n.Indirects, Name: n.Name,
+		Key: exported(n.Key), Addrs: n.Addrs, Len: n.Len, Cap: n.Cap, Value: n.Value,
+		Method: n.Method, Panic: n.Panic, Bytes: n.Bytes, More: n.More, Nil: n.Nil,
+		Invalid: n.Invalid, Circular: n.Circular, Ref: n.Ref, MaxDepth: n.MaxDepth,
+		Redacted: n.Redacted}
+	for _, child := range n.Children {
+		c.Children = append(c.Children, exported(child))
+	}
+	return c
+}
+
+// TestWalk ensures Walk describes values the way Dump displays them.
+func TestWalk(t *testing.T) {
+	count := 3
+	v := &walkTester{
+		Name:  "a",
+		Count: &count,
+		Tags:  map[string]int{"b": 2, "a": 1},
+		Data:  []byte("xy"),
+		Err:   walkErr("boom"),
+	}
+	v.Next = v
+
+	cs := &spew.ConfigState{SortKeys: true, PointerIDs: true, MaxElements: 2, MaxStringLen: 2}
+
+	tests := []struct {
+		in   interface{}
+		want *spew.Node
+	}{
+		{v, &spew.Node{Type: "*spew_test.walkTester", Kind: reflect.Struct, Indirects: 1,
+			Addrs: []string{"&1"}, Children: []*spew.Node{
+				{Name: "Name", Type: "string", Kind: reflect.String, Len: 1, Value: "a"},
+				{Name: "Count", Type: "*int", Kind: reflect.Int, Indirects: 1,
+					Addrs: []string{"&2"}, Value: "3"},
+				{Name: "Tags", Type: "map[string]int", Kind: reflect.Map, Len: 2, Children: []*spew.Node{
+					{Key: &spew.Node{Type: "string", Kind: reflect.String, Len: 1, Value: "a"},
+						Type: "int", Kind: reflect.Int, Value: "1"},
+					{Key: &spew.Node{Type: "string", Kind: reflect.String, Len: 1, Value: "b"},
+						Type: "int", Kind: reflect.Int, Value: "2"},
+				}},
+				{Name: "Data", Type: "[]uint8", Kind: reflect.Slice, Len: 2, Cap: 2, Bytes: []byte("xy")},
+				{Name: "Any", Type: "interface {}", Kind: reflect.Interface, Nil: true},
+				{Name: "Err", Type: "spew_test.walkErr", Kind: reflect.String, Len: 4, Method: "boom"},
+				{Name: "Next", Type: "*spew_test.walkTester", Kind: reflect.Ptr, Ref: "&1"},
+			}}},
+		{[]string{"abc", "d", "e"}, &spew.Node{Type: "[]string", Kind: reflect.Slice, Len: 3, Cap: 3,
+			More: 1, Children: []*spew.Node{
+				{Type: "string", Kind: reflect.String, Len: 3, Value: "ab", More: 1},
+				{Type: "string", Kind: reflect.String, Len: 1, Value: "d"},
+			}}},
+		{(*int)(nil), &spew.Node{Type: "*int", Kind: reflect.Ptr, Nil: true}},
+		{nil, &spew.Node{Type: "interface {}", Kind: reflect.Interface, Nil: true}},
+	}
+
+	for i, test := range tests {
+		got := exported(spew.Walk(cs, test.in))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Walk #%d\n got: %s want: %s", i, spew.Sdump(got), spew.Sdump(test.want))
+		}
+	}
+}
+
+// TestWalkCustom ensures the output of custom formatters is collected in the
+// nodes of the values they render.
+func TestWalkCustom(t *testing.T) {
+	cs := &spew.ConfigState{}
+	cs.RegisterFormatter(reflect.TypeOf(walkPoint{}), func(p *spew.Printer, v reflect.Value) {
+		pt := v.Interface().(walkPoint)
+		fmt.Fprintf(p, "sum=%d", pt.X+pt.Y)
+		p.Field("X", pt.X)
+		p.Field("Y", pt.Y)
+	})
+
+	want := &spew.Node{Type: "spew_test.walkPoint", Kind: reflect.Struct, Value: "sum=3",
+		Children: []*spew.Node{
+			{Name: "X", Type: "int", Kind: reflect.Int, Value: "1"},
+			{Name: "Y", Type: "int", Kind: reflect.Int, Value: "2"},
+		}}
+
+	got := exported(spew.Walk(cs, walkPoint{1, 2}))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkCustom\n got: %s want: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}